@@ -0,0 +1,517 @@
+package workflow
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// The struct tags which bind the request parts to the handler input fields.
+const (
+	pathTag           = "path"
+	queryTag          = "query"
+	headerTag         = "header"
+	authorizerTag     = "authorizer"
+	claimTag          = "claim"
+	requestContextTag = "requestContext"
+	stageVariableTag  = "stageVariable"
+	bodyTag           = "body"
+)
+
+var (
+	valueTags = []string{
+		pathTag, queryTag, headerTag, authorizerTag, claimTag, requestContextTag, stageVariableTag,
+	}
+	bindingTags         = append(valueTags, bodyTag)
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	durationType        = reflect.TypeOf(time.Duration(0))
+)
+
+// FieldError describes error of single field of the handler input.
+type FieldError struct {
+	// Field is the path of the struct field like Filter.Limit. It is
+	// empty if the error is not related to single field.
+	Field string `json:"field,omitempty"`
+	// Source is the part of the request from which the field value
+	// is taken like path, query, header or body.
+	Source string `json:"source,omitempty"`
+	// Name is the name of the value in the source.
+	Name string `json:"name,omitempty"`
+	// Rule is the name of the validation rule which the field does not match.
+	Rule    string `json:"rule,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	name := e.Field
+	if len(e.Source) > 0 {
+		name = strings.TrimSpace(fmt.Sprintf("%s (%s %s)", name, e.Source, e.Name))
+	}
+
+	if len(name) == 0 {
+		return e.Message
+	}
+
+	return name + ": " + e.Message
+}
+
+// BindingError is the error returned when the request can not be bound
+// to the handler input. It contains the errors of all invalid fields.
+type BindingError struct {
+	Fields []FieldError `json:"fields"`
+}
+
+func (e *BindingError) Error() string {
+	return "invalid request: " + joinFieldErrors(e.Fields)
+}
+
+func joinFieldErrors(errs []FieldError) string {
+	msgs := make([]string, 0, len(errs))
+	for _, f := range errs {
+		msgs = append(msgs, f.Error())
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// requestBinder binds the parts of API Gateway proxy request to the
+// handler input struct fields by their tags:
+//
+//	type Input struct {
+//		ID     int       `path:"id"`
+//		Limit  int       `query:"limit"`
+//		Tenant string    `header:"X-Tenant"`
+//		Since  time.Time `query:"since"`
+//		Tags   []string  `query:"tag"`
+//		Data   Data      `body:""`
+//	}
+//
+// The authorizer, claim, requestContext and stageVariable tags bind the
+// values of the API Gateway request context authorizer, the authorizer
+// claims, the request context and the stage variables:
+//
+//	type Input struct {
+//		PrincipalID string `authorizer:"principalId"`
+//		Email       string `claim:"email"`
+//		SourceIP    string `requestContext:"identity.sourceIp"`
+//		Table       string `stageVariable:"tableName"`
+//	}
+//
+// The authorizer and requestContext names are paths of the values in the
+// authorizer map and in the JSON of the request context separated by dot.
+//
+// The slice fields are bound to all values of repeated or comma-separated
+// parameters. If there is no field with body tag, the body is decoded by
+// the decoder of the request Content-Type in the input struct.
+type requestBinder struct {
+	evt        events.APIGatewayProxyRequest
+	pathParams map[string]string
+	// The decoder of the request body and the Content-Type parameters.
+	decoder Decoder
+	params  map[string]string
+	errs    []FieldError
+	// requestContext is the request context as JSON map.
+	requestContext map[string]interface{}
+}
+
+// bind binds the request to the value pointed by out.
+func (b *requestBinder) bind(out interface{}) Error {
+	input := reflect.ValueOf(out)
+	structType := input.Type().Elem()
+	if structType.Kind() != reflect.Struct {
+		b.bindBody(input.Elem(), "")
+	} else {
+		if !hasBodyField(structType) && len(b.evt.Body) > 0 {
+			b.decodeBody(input.Interface(), "")
+		}
+
+		b.bindStruct(input.Elem(), "")
+	}
+
+	if len(b.errs) > 0 {
+		return newError(&BindingError{Fields: b.errs})
+	}
+
+	return nil
+}
+
+func (b *requestBinder) bindStruct(v reflect.Value, prefix string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if isEmbeddedStruct(f) {
+			b.bindStruct(v.Field(i), prefix)
+			continue
+		}
+
+		// Skip the unexported fields.
+		if len(f.PkgPath) > 0 {
+			continue
+		}
+
+		field := prefix + f.Name
+		if _, ok := f.Tag.Lookup(bodyTag); ok {
+			b.bindBody(v.Field(i), field)
+			continue
+		}
+
+		for _, tag := range valueTags {
+			value, ok := f.Tag.Lookup(tag)
+			if !ok {
+				continue
+			}
+
+			name, split := parseBindingTag(value, f.Name)
+			values, ok := b.getValues(tag, name)
+			if !ok {
+				continue
+			}
+
+			setFieldValues(v.Field(i), field, tag, name, values, split, &b.errs)
+		}
+	}
+}
+
+func (b *requestBinder) bindBody(v reflect.Value, field string) {
+	switch {
+	case v.Kind() == reflect.String:
+		v.SetString(b.evt.Body)
+	case isRawBodyType(v.Type()):
+		v.SetBytes([]byte(b.evt.Body))
+	case len(b.evt.Body) > 0:
+		b.decodeBody(v.Addr().Interface(), field)
+	}
+}
+
+// decodeBody decodes the body with the decoder of the request Content-Type.
+func (b *requestBinder) decodeBody(out interface{}, field string) {
+	err := b.decoder([]byte(b.evt.Body), b.params, out)
+	if bErr, ok := err.(*BindingError); ok {
+		for _, f := range bErr.Fields {
+			f.Field = joinFieldPath(field, f.Field)
+			b.errs = append(b.errs, f)
+		}
+	} else if err != nil {
+		b.errs = append(b.errs, FieldError{Field: field, Source: bodyTag, Message: err.Error()})
+	}
+}
+
+// getValues returns the values of the parameter from the request source.
+// The multi-value query parameters and headers are preferred over the
+// single-value ones and the headers are matched case-insensitively.
+func (b *requestBinder) getValues(source, name string) ([]string, bool) {
+	switch source {
+	case pathTag:
+		v, ok := b.pathParams[name]
+		return []string{v}, ok
+	case queryTag:
+		if v, ok := b.evt.MultiValueQueryStringParameters[name]; ok && len(v) > 0 {
+			return v, true
+		}
+
+		v, ok := b.evt.QueryStringParameters[name]
+		return []string{v}, ok
+	case headerTag:
+		if v, ok := getMultiValueHeader(b.evt.MultiValueHeaders, name); ok && len(v) > 0 {
+			return v, true
+		}
+
+		v, ok := getHeader(b.evt.Headers, name)
+		return []string{v}, ok
+	case authorizerTag:
+		return toStrings(lookupPath(b.evt.RequestContext.Authorizer, name))
+	case claimTag:
+		claims, _ := lookupPath(b.evt.RequestContext.Authorizer, "claims")
+		return toStrings(lookupPath(claims, name))
+	case requestContextTag:
+		return toStrings(lookupPath(b.getRequestContext(), name))
+	case stageVariableTag:
+		v, ok := b.evt.StageVariables[name]
+		return []string{v}, ok
+	}
+
+	return nil, false
+}
+
+func (b *requestBinder) getRequestContext() map[string]interface{} {
+	if b.requestContext == nil {
+		b.requestContext = make(map[string]interface{})
+		if ctxBytes, err := json.Marshal(b.evt.RequestContext); err == nil {
+			json.Unmarshal(ctxBytes, &b.requestContext)
+		}
+	}
+
+	return b.requestContext
+}
+
+func newRequestBinder(evt events.APIGatewayProxyRequest, pathParams map[string]string, decoder Decoder, params map[string]string) *requestBinder {
+	return &requestBinder{evt: evt, pathParams: pathParams, decoder: decoder, params: params, errs: []FieldError{}}
+}
+
+// setFieldValues sets the values to the field. The slice fields are set to
+// all values and the values are split by comma if split is true, the other
+// fields are set to the last value.
+func setFieldValues(v reflect.Value, field, source, name string, values []string, split bool, errs *[]FieldError) {
+	if !isSliceField(v.Type()) {
+		if err := setValue(v, values[len(values)-1]); err != nil {
+			*errs = append(*errs, FieldError{Field: field, Source: source, Name: name, Message: err.Error()})
+		}
+
+		return
+	}
+
+	if split {
+		values = splitValues(values)
+	}
+
+	res := reflect.MakeSlice(v.Type(), len(values), len(values))
+	for i, value := range values {
+		if err := setValue(res.Index(i), value); err != nil {
+			f := fmt.Sprintf("%s[%d]", field, i)
+			*errs = append(*errs, FieldError{Field: f, Source: source, Name: name, Message: err.Error()})
+		}
+	}
+
+	v.Set(res)
+}
+
+// lookupPath returns the value with path like identity.sourceIp in the
+// nested maps.
+func lookupPath(value interface{}, path string) (interface{}, bool) {
+	for _, key := range strings.Split(path, ".") {
+		var ok bool
+		switch m := value.(type) {
+		case map[string]interface{}:
+			value, ok = m[key]
+		case map[string]string:
+			value, ok = m[key]
+		}
+
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return value, true
+}
+
+// toStrings converts the value decoded from JSON to strings which can be
+// set to the input fields.
+func toStrings(value interface{}, ok bool) ([]string, bool) {
+	if !ok || value == nil {
+		return nil, false
+	}
+
+	switch v := value.(type) {
+	case []string:
+		return v, len(v) > 0
+	case []interface{}:
+		res := make([]string, 0, len(v))
+		for _, e := range v {
+			if s, ok := toStrings(e, true); ok {
+				res = append(res, s...)
+			}
+		}
+
+		return res, len(res) > 0
+	case float64:
+		return []string{strconv.FormatFloat(v, 'f', -1, 64)}, true
+	}
+
+	return []string{fmt.Sprint(value)}, true
+}
+
+// setValue converts the string to the type of the value and sets it.
+func setValue(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Ptr {
+		ptr := reflect.New(v.Type().Elem())
+		if err := setValue(ptr.Elem(), s); err != nil {
+			return err
+		}
+
+		v.Set(ptr)
+		return nil
+	}
+
+	if v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("cannot convert %q to %s", s, v.Type())
+		}
+
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	var err error
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("unsupported field type %s", v.Type())
+		}
+
+		v.SetBytes([]byte(s))
+	case reflect.Bool:
+		var res bool
+		res, err = strconv.ParseBool(s)
+		v.SetBool(res)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var res int64
+		res, err = strconv.ParseInt(s, 10, v.Type().Bits())
+		v.SetInt(res)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var res uint64
+		res, err = strconv.ParseUint(s, 10, v.Type().Bits())
+		v.SetUint(res)
+	case reflect.Float32, reflect.Float64:
+		var res float64
+		res, err = strconv.ParseFloat(s, v.Type().Bits())
+		v.SetFloat(res)
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+
+	if err != nil {
+		return fmt.Errorf("cannot convert %q to %s", s, v.Type())
+	}
+
+	return nil
+}
+
+// parseBindingTag returns the parameter name from tag value like "tag,nosplit"
+// and whether the values should be split by comma. The field name is used if
+// the tag does not have name.
+func parseBindingTag(tag, fieldName string) (name string, split bool) {
+	parts := strings.Split(tag, ",")
+	name, split = parts[0], true
+	for _, opt := range parts[1:] {
+		if opt == "nosplit" {
+			split = false
+		}
+	}
+
+	if len(name) == 0 {
+		name = fieldName
+	}
+
+	return name, split
+}
+
+// splitValues splits the comma-separated values, so both ?tag=a,b and
+// ?tag=a&tag=b result in the same values.
+func splitValues(values []string) []string {
+	res := []string{}
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); len(part) > 0 {
+				res = append(res, part)
+			}
+		}
+	}
+
+	return res
+}
+
+// isSliceField reports whether the field is bound to all parameter values.
+// The []byte fields and the types which can unmarshal text are bound to
+// single value.
+func isSliceField(t reflect.Type) bool {
+	if t.Kind() != reflect.Slice || t.Elem().Kind() == reflect.Uint8 {
+		return false
+	}
+
+	return !reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+// isRawBodyType reports whether the raw request body is set to the values
+// of the type if the request body is not JSON.
+func isRawBodyType(t reflect.Type) bool {
+	return t.Kind() == reflect.String || (t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8)
+}
+
+// hasBindingTags reports whether the type is struct or pointer to struct
+// with fields bound by tags.
+func hasBindingTags(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if isEmbeddedStruct(f) && hasBindingTags(f.Type) {
+			return true
+		}
+
+		for _, tag := range bindingTags {
+			if _, ok := f.Tag.Lookup(tag); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func hasBodyField(t reflect.Type) bool {
+	_, ok := getBodyField(t)
+	return ok
+}
+
+// getBodyField returns the field with body tag of the struct type.
+func getBodyField(t reflect.Type) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if isEmbeddedStruct(f) {
+			if bf, ok := getBodyField(f.Type); ok {
+				return bf, true
+			}
+		}
+
+		if _, ok := f.Tag.Lookup(bodyTag); ok {
+			return f, true
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
+// hasRawBody reports whether the raw body is set to the input or to its
+// field with body tag, so the bodies of any content type are accepted.
+func hasRawBody(t reflect.Type) bool {
+	if isRawBodyType(t) {
+		return true
+	}
+
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+
+	f, ok := getBodyField(t)
+	return ok && isRawBodyType(f.Type)
+}
+
+// isEmbeddedStruct reports whether the field is embedded struct without
+// tags. The fields of such structs are bound as fields of the parent.
+func isEmbeddedStruct(f reflect.StructField) bool {
+	return f.Anonymous && f.Type.Kind() == reflect.Struct && len(f.Tag) == 0
+}
@@ -0,0 +1,234 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type upperText string
+
+func (t *upperText) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		return fmt.Errorf("empty text")
+	}
+
+	*t = upperText(strings.ToUpper(string(text)))
+	return nil
+}
+
+type bindingPaging struct {
+	Limit  int   `query:"limit"`
+	Offset *uint `query:"offset"`
+}
+
+type bindingReq struct {
+	bindingPaging
+	ID      int64         `path:"id"`
+	Tenant  string        `header:"X-Tenant"`
+	Active  bool          `query:"active"`
+	Ratio   float64       `query:"ratio"`
+	Since   time.Time     `query:"since"`
+	Timeout time.Duration `query:"timeout"`
+	Code    upperText     `query:"code"`
+	Message string        `json:"message"`
+}
+
+type bindingMultiValueReq struct {
+	Tags    []string `query:"tag"`
+	IDs     []int    `query:"id"`
+	Filter  []string `query:"filter,nosplit"`
+	Accept  []string `header:"accept"`
+	Trace   string   `header:"X-Trace"`
+	Payload []byte   `query:"payload"`
+}
+
+type bindingContextReq struct {
+	PrincipalID string   `authorizer:"principalId"`
+	TenantID    int      `authorizer:"tenant.id"`
+	Email       string   `claim:"email"`
+	Groups      []string `claim:"groups"`
+	SourceIP    string   `requestContext:"identity.sourceIp"`
+	Stage       string   `requestContext:"stage"`
+	RequestID   string   `requestContext:"requestId"`
+	Table       string   `stageVariable:"tableName"`
+}
+
+type bindingBodyReq struct {
+	ID   string  `path:"id"`
+	Data JSONReq `body:""`
+}
+
+func TestRequestBinder(t *testing.T) {
+	Convey("Request binder", t, func() {
+		evt := events.APIGatewayProxyRequest{
+			Headers: map[string]string{"x-tenant": "acme"},
+			QueryStringParameters: map[string]string{
+				"limit":   "10",
+				"offset":  "20",
+				"active":  "true",
+				"ratio":   "0.5",
+				"since":   "2020-01-02T15:04:05Z",
+				"timeout": "1m",
+				"code":    "abc",
+				"message": "from query",
+			},
+			Body: `{"message":"from body"}`,
+		}
+		pathParams := map[string]string{"id": "5"}
+
+		Convey("Should bind the tagged fields with type conversion.", func() {
+			req := bindingReq{}
+			err := newRequestBinder(evt, pathParams, decodeJSON, nil).bind(&req)
+			So(err, ShouldBeNil)
+
+			offset := uint(20)
+			So(req, ShouldResemble, bindingReq{
+				bindingPaging: bindingPaging{Limit: 10, Offset: &offset},
+				ID:            5,
+				Tenant:        "acme",
+				Active:        true,
+				Ratio:         0.5,
+				Since:         time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC),
+				Timeout:       time.Minute,
+				Code:          "ABC",
+				Message:       "from body",
+			})
+		})
+
+		Convey("Should bind the multi-value parameters to slice fields.", func() {
+			evt.MultiValueQueryStringParameters = map[string][]string{
+				"tag":    {"a", "b,c"},
+				"id":     {"1,2"},
+				"filter": {"x,y", "z"},
+			}
+			evt.QueryStringParameters["payload"] = "data"
+			evt.MultiValueHeaders = map[string][]string{
+				"Accept":  {"text/html, application/json"},
+				"x-trace": {"1", "2"},
+			}
+
+			req := bindingMultiValueReq{}
+			err := newRequestBinder(evt, pathParams, decodeJSON, nil).bind(&req)
+			So(err, ShouldBeNil)
+			So(req, ShouldResemble, bindingMultiValueReq{
+				Tags:    []string{"a", "b", "c"},
+				IDs:     []int{1, 2},
+				Filter:  []string{"x,y", "z"},
+				Accept:  []string{"text/html", "application/json"},
+				Trace:   "2",
+				Payload: []byte("data"),
+			})
+		})
+
+		Convey("Should return the conversion errors of the slice elements.", func() {
+			evt.MultiValueQueryStringParameters = map[string][]string{"id": {"1", "x"}}
+
+			err := newRequestBinder(evt, pathParams, decodeJSON, nil).bind(&bindingMultiValueReq{})
+			So(err, ShouldNotBeNil)
+			So(err.OriginalError().(*BindingError).Fields[0].Field, ShouldEqual, "IDs[1]")
+		})
+
+		Convey("Should bind the request context, authorizer and stage variables values.", func() {
+			evt.RequestContext = events.APIGatewayProxyRequestContext{
+				Stage:     "prod",
+				RequestID: "request-1",
+				Identity:  events.APIGatewayRequestIdentity{SourceIP: "10.0.0.1"},
+				Authorizer: map[string]interface{}{
+					"principalId": "user-1",
+					"tenant":      map[string]interface{}{"id": float64(42)},
+					"claims": map[string]interface{}{
+						"email":  "user@example.com",
+						"groups": []interface{}{"admin", "users"},
+					},
+				},
+			}
+			evt.StageVariables = map[string]string{"tableName": "items"}
+
+			req := bindingContextReq{}
+			err := newRequestBinder(evt, pathParams, decodeJSON, nil).bind(&req)
+			So(err, ShouldBeNil)
+			So(req, ShouldResemble, bindingContextReq{
+				PrincipalID: "user-1",
+				TenantID:    42,
+				Email:       "user@example.com",
+				Groups:      []string{"admin", "users"},
+				SourceIP:    "10.0.0.1",
+				Stage:       "prod",
+				RequestID:   "request-1",
+				Table:       "items",
+			})
+		})
+
+		Convey("Should bind the body to the field with body tag.", func() {
+			evt.Body = getStringBody(JSONReq{Message: "test", Code: 1})
+			req := bindingBodyReq{}
+			err := newRequestBinder(evt, pathParams, decodeJSON, nil).bind(&req)
+			So(err, ShouldBeNil)
+			So(req, ShouldResemble, bindingBodyReq{ID: "5", Data: JSONReq{Message: "test", Code: 1}})
+		})
+
+		Convey("Should return the conversion errors of all fields.", func() {
+			evt.QueryStringParameters["limit"] = "ten"
+			evt.QueryStringParameters["since"] = "yesterday"
+			evt.QueryStringParameters["code"] = ""
+			pathParams["id"] = "x"
+
+			err := newRequestBinder(evt, pathParams, decodeJSON, nil).bind(&bindingReq{})
+			So(err, ShouldNotBeNil)
+
+			bErr, ok := err.OriginalError().(*BindingError)
+			So(ok, ShouldBeTrue)
+			So(len(bErr.Fields), ShouldEqual, 4)
+			So(bErr.Fields[0], ShouldResemble, FieldError{Field: "Limit", Source: "query", Name: "limit", Message: `cannot convert "ten" to int`})
+			So(bErr.Fields[1], ShouldResemble, FieldError{Field: "ID", Source: "path", Name: "id", Message: `cannot convert "x" to int64`})
+			So(bErr.Fields[2].Field, ShouldEqual, "Since")
+			So(bErr.Fields[3], ShouldResemble, FieldError{Field: "Code", Source: "query", Name: "code", Message: "empty text"})
+			So(err.Error(), ShouldStartWith, `invalid request: Limit (query limit): cannot convert "ten" to int; ID (path id)`)
+		})
+
+		Convey("Should return error for invalid body.", func() {
+			evt.Body = "{"
+			err := newRequestBinder(evt, pathParams, decodeJSON, nil).bind(&bindingBodyReq{})
+			So(err, ShouldNotBeNil)
+			So(err.OriginalError().(*BindingError).Fields[0].Field, ShouldEqual, "Data")
+		})
+
+		Convey("Should be used by the proxy workflow for inputs with binding tags.", func() {
+			var input bindingReq
+			h := NewAPIGWProxyWorkflowBuilder().
+				AddPostHandler("/items/{id}", func(c Context, req bindingReq) error {
+					input = req
+					return nil
+				}).
+				Build().
+				GetLambdaHandler()
+
+			evt.HTTPMethod = http.MethodPost
+			evt.Path = "/items/7"
+			_, err := h(nil, evt)
+			So(err, ShouldBeNil)
+			So(input.ID, ShouldEqual, 7)
+			So(input.Limit, ShouldEqual, 10)
+			So(input.Message, ShouldEqual, "from body")
+
+			evt.Path = "/items/x"
+			res, err := h(nil, evt)
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusBadRequest)
+
+			body := ErrorResponse{}
+			So(json.Unmarshal([]byte(res.Body), &body), ShouldBeNil)
+			So(body, ShouldResemble, ErrorResponse{
+				Message: "invalid request",
+				Errors:  []FieldError{{Field: "ID", Source: "path", Name: "id", Message: `cannot convert "x" to int64`}},
+			})
+		})
+	})
+}
@@ -3,14 +3,15 @@ package workflow
 // APIGWAuthorizerWorkflowBuilder AWS Lambda handler workflow builder.
 type APIGWAuthorizerWorkflowBuilder struct {
 	*BaseWorkflowBuilder
-	handler *handlerData
+	handler    *handlerData
+	handlerErr error
 }
 
 // SetHandler sets the provided handler as the API GW Authorizer.
 func (b *APIGWAuthorizerWorkflowBuilder) SetHandler(handler interface{}) *APIGWAuthorizerPrePostHandlerActionBuilder {
-	// TODO: Validate handler func.
 	hData := &handlerData{handler: handler, preActions: []Action{}, postActions: []Action{}}
 	b.handler = hData
+	b.handlerErr = validateHandler(handler)
 	return newAPIGWAuthorizerPrePostHandlerActionBuilder(b)
 }
 
@@ -32,12 +33,44 @@ func (b *APIGWAuthorizerWorkflowBuilder) AddPostActions(actions ...Action) *APIG
 	return b
 }
 
-// Build creates the AWS Lambda workflow.
+// AddValidationRule adds validation rule which can be used in the validate
+// tags of the handler inputs.
+func (b *APIGWAuthorizerWorkflowBuilder) AddValidationRule(name string, rule ValidationRule) *APIGWAuthorizerWorkflowBuilder {
+	b.BaseWorkflowBuilder.AddValidationRule(name, rule)
+	return b
+}
+
+// Build creates the AWS Lambda workflow. Panics if the handler is
+// not set or is invalid. Use BuildE to handle the error.
 func (b *APIGWAuthorizerWorkflowBuilder) Build() *APIGatewayAuthorizerWorkflow {
+	w, err := b.BuildE()
+	if err != nil {
+		panic(err)
+	}
+
+	return w
+}
+
+// BuildE creates the AWS Lambda workflow. Returns error if the
+// handler is not set or is invalid.
+func (b *APIGWAuthorizerWorkflowBuilder) BuildE() (*APIGatewayAuthorizerWorkflow, Error) {
+	if b.handler == nil {
+		return nil, newErrorWithMessage("the authorizer handler is not set")
+	}
+
+	if b.handlerErr != nil {
+		return nil, newErrorWithMessage("invalid authorizer handler: %s", b.handlerErr)
+	}
+
+	base := b.BaseWorkflowBuilder.Build()
+	if err := checkHandlerInput(b.handler.handler, base.validator); err != nil {
+		return nil, newErrorWithMessage("invalid authorizer handler: %s", err)
+	}
+
 	return &APIGatewayAuthorizerWorkflow{
-		BaseWorkflow: b.BaseWorkflowBuilder.Build(),
+		BaseWorkflow: base,
 		handler:      b.handler,
-	}
+	}, nil
 }
 
 // NewAPIGWAuthorizerWorkflowBuilder creates new AWS API Gateway Authorizer workflow builder.
@@ -1,19 +1,25 @@
 package workflow
 
 import (
+	"fmt"
 	"net/http"
-	"regexp"
-)
-
-var (
-	parameterizedPathRegExp = regexp.MustCompile(`.*(\{.*\}).*`)
+	"reflect"
+	"strings"
 )
 
 // APIGWProxyWorkflowBuilder AWS Lambda handler workflow builder.
 type APIGWProxyWorkflowBuilder struct {
 	*BaseWorkflowBuilder
-	httpHandlers              map[string]*handlerData
-	parameterizedHTTPHandlers []*parameterizedHandlerData
+	routes            []*route
+	notFound          *route
+	pathNormalization PathNormalization
+	resourceRouting   bool
+	decoders          map[string]Decoder
+	decodingOptions   DecodingOptions
+	maxBodySize       int
+	// errs are the errors of the invalid builder settings which
+	// are returned when the workflow is built.
+	errs []string
 }
 
 // AddGetHandler adds the provided handler to the specified path and GET HTTP method.
@@ -36,24 +42,111 @@ func (b *APIGWProxyWorkflowBuilder) AddDeleteHandler(path string, handler interf
 	return b.AddMethodHandler(http.MethodDelete, path, handler)
 }
 
+// AddHeadHandler adds the provided handler to the specified path and HEAD HTTP method.
+// If there is no HEAD handler for the path, the GET handler is used and the response
+// body is dropped.
+func (b *APIGWProxyWorkflowBuilder) AddHeadHandler(path string, handler interface{}) *APIGWPrePostHandlerActionBuilder {
+	return b.AddMethodHandler(http.MethodHead, path, handler)
+}
+
+// AddOptionsHandler adds the provided handler to the specified path and OPTIONS HTTP method.
+// If there is no OPTIONS handler for the path, the workflow responds with the allowed methods.
+func (b *APIGWProxyWorkflowBuilder) AddOptionsHandler(path string, handler interface{}) *APIGWPrePostHandlerActionBuilder {
+	return b.AddMethodHandler(http.MethodOptions, path, handler)
+}
+
 // AddMethodHandler adds the provided handler to the specified path with the provided HTTP method.
 func (b *APIGWProxyWorkflowBuilder) AddMethodHandler(httpMethod, path string, handler interface{}) *APIGWPrePostHandlerActionBuilder {
-	// TODO: Validate handler func.
+	rt := b.addRoute(httpMethod, path, handler, nil)
+	return newAPIGWPrePostHandlerActionBuilder(b, rt)
+}
+
+// SetNotFoundHandler sets the handler which is invoked when there is no handler
+// for the request path. The handler is invoked with the workflow actions like
+// any other handler. By default the workflow responds with 404 status code.
+func (b *APIGWProxyWorkflowBuilder) SetNotFoundHandler(handler interface{}) *APIGWNotFoundHandlerActionBuilder {
 	hData := &handlerData{handler: handler, preActions: []Action{}, postActions: []Action{}}
+	b.notFound = &route{hData: hData}
+	if err := validateHandler(handler); err != nil {
+		b.notFound.err = fmt.Errorf("invalid not found handler: %s", err)
+	}
+
+	return &APIGWNotFoundHandlerActionBuilder{APIGWProxyWorkflowBuilder: b, routeSettings: newRouteSettings(b.notFound)}
+}
+
+// Mount adds the routes of the provided workflow with the path prefix. The
+// mounted routes are invoked with the bootstrap and the actions of the mounted
+// workflow, which are wrapped by the actions of the current workflow. The
+// request body decoders, decoding options and max body size of the mounted
+// workflow are used for its routes. The not found handler and the routing
+// options of the mounted workflow are not used.
+func (b *APIGWProxyWorkflowBuilder) Mount(prefix string, w *APIGatewayProxyWorkflow) *APIGWProxyWorkflowBuilder {
+	if err := validatePathPrefix(prefix); err != nil {
+		b.errs = append(b.errs, fmt.Sprintf("invalid mounted workflow: %s", err))
+	}
 
-	// TODO: Check if path already exist.
-	if b.isParameterizedPath(path) {
-		phData := &parameterizedHandlerData{
-			hData:        hData,
-			pathRegExp:   regexp.MustCompile(parameterizedPathRegExp.ReplaceAllString(path, "(.*)")),
-			originalPath: path,
-			method:       httpMethod,
+	for _, rt := range w.router.routes {
+		mounted := b.addRoute(rt.method, joinPath(prefix, rt.path), rt.hData.handler, nil)
+		mounted.conditions = append(mounted.conditions, rt.conditions...)
+		mounted.hData.mount = &mountData{workflow: w.BaseWorkflow, hData: rt.hData}
+		mounted.hData.decodingOptions = w.getDecodingOptions(rt.hData)
+		mounted.hData.maxBodySize = rt.hData.maxBodySize
+		if mounted.hData.maxBodySize == 0 {
+			mounted.hData.maxBodySize = w.maxBodySize
 		}
-		b.parameterizedHTTPHandlers = append(b.parameterizedHTTPHandlers, phData)
-	} else {
-		b.httpHandlers[getHandlerKey(httpMethod, path)] = hData
+
+		mounted.hData.decoders = w.decoders
+		mounted.hData.typed = rt.hData.typed
 	}
-	return newAPIGWPrePostHandlerActionBuilder(b, hData)
+
+	return b
+}
+
+// SetPathNormalization sets how the request path is normalized before it is
+// matched to the workflow routes.
+func (b *APIGWProxyWorkflowBuilder) SetPathNormalization(n PathNormalization) *APIGWProxyWorkflowBuilder {
+	b.pathNormalization = n
+	return b
+}
+
+// SetResourceRouting enables matching the requests by the API Gateway resource
+// path and HTTP method instead of by the request path. The request path is
+// still matched for greedy resources like /{proxy+} and for requests without
+// resource path.
+func (b *APIGWProxyWorkflowBuilder) SetResourceRouting(enabled bool) *APIGWProxyWorkflowBuilder {
+	b.resourceRouting = enabled
+	return b
+}
+
+// AddDecoder adds decoder of the request bodies with the provided media type
+// like text/csv. The decoder replaces the default decoder of the media type.
+// The requests with body with media type without decoder are responded with
+// 415 status code.
+func (b *APIGWProxyWorkflowBuilder) AddDecoder(mediaType string, decoder Decoder) *APIGWProxyWorkflowBuilder {
+	b.decoders[strings.ToLower(mediaType)] = decoder
+	return b
+}
+
+// SetDecodingOptions sets the options of the JSON request bodies decoding.
+// The options replace the decoder of the JSON media type.
+func (b *APIGWProxyWorkflowBuilder) SetDecodingOptions(opts DecodingOptions) *APIGWProxyWorkflowBuilder {
+	b.decodingOptions = opts
+	return b
+}
+
+// SetMaxBodySize sets the max size of the request body in bytes. The
+// requests with larger bodies are responded with 413 status code before
+// the body is decoded. There is no limit if the size is not positive.
+func (b *APIGWProxyWorkflowBuilder) SetMaxBodySize(size int) *APIGWProxyWorkflowBuilder {
+	b.maxBodySize = size
+	return b
+}
+
+// Group creates route group with the provided path prefix. The routes added
+// to the group are registered in the workflow with the group prefix and
+// the group actions.
+func (b *APIGWProxyWorkflowBuilder) Group(prefix string) *APIGWProxyRouteGroup {
+	return newAPIGWProxyRouteGroup(b, nil, prefix)
 }
 
 // SetBootstrap override just to return the correct builder.
@@ -74,20 +167,57 @@ func (b *APIGWProxyWorkflowBuilder) AddPostActions(actions ...Action) *APIGWProx
 	return b
 }
 
-// Build creates the AWS Lambda workflow.
+// AddValidationRule adds validation rule which can be used in the validate
+// tags of the handler inputs.
+func (b *APIGWProxyWorkflowBuilder) AddValidationRule(name string, rule ValidationRule) *APIGWProxyWorkflowBuilder {
+	b.BaseWorkflowBuilder.AddValidationRule(name, rule)
+	return b
+}
+
+// Build creates the AWS Lambda workflow. Panics if there are invalid,
+// duplicate or ambiguous routes. Use BuildE to handle the error.
 func (b *APIGWProxyWorkflowBuilder) Build() *APIGatewayProxyWorkflow {
-	return &APIGatewayProxyWorkflow{
-		BaseWorkflow:              b.BaseWorkflowBuilder.Build(),
-		httpHandlers:              b.httpHandlers,
-		parameterizedHTTPHandlers: b.parameterizedHTTPHandlers,
+	w, err := b.BuildE()
+	if err != nil {
+		panic(err)
 	}
+
+	return w
+}
+
+// BuildE creates the AWS Lambda workflow. Returns error which lists all
+// invalid, duplicate and ambiguous routes and all routes with invalid
+// handlers if there are any.
+func (b *APIGWProxyWorkflowBuilder) BuildE() (*APIGatewayProxyWorkflow, Error) {
+	base := b.BaseWorkflowBuilder.Build()
+	r, err := b.buildRouter(base.validator)
+	if err != nil {
+		return nil, err
+	}
+
+	base.invalidRequestHandler = setValidationErrorResponse
+
+	return &APIGatewayProxyWorkflow{
+		BaseWorkflow:      base,
+		router:            r,
+		notFoundHandler:   b.notFound.hData,
+		pathNormalization: b.pathNormalization,
+		resourceRouting:   b.resourceRouting,
+		decoders:          b.decoders,
+		decodingOptions:   b.decodingOptions,
+		maxBodySize:       b.maxBodySize,
+	}, nil
 }
 
 // NewAPIGWProxyWorkflowBuilder creates new AWS API Gateway Proxy workflow builder.
 func NewAPIGWProxyWorkflowBuilder() *APIGWProxyWorkflowBuilder {
 	return &APIGWProxyWorkflowBuilder{
 		BaseWorkflowBuilder: NewBaseWorkflowBuilder(),
-		httpHandlers:        make(map[string]*handlerData),
+		routes:              []*route{},
+		notFound: &route{
+			hData: &handlerData{handler: defaultNotFoundHandler, preActions: []Action{}, postActions: []Action{}},
+		},
+		decoders: getDefaultDecoders(),
 	}
 }
 
@@ -95,38 +225,206 @@ func NewAPIGWProxyWorkflowBuilder() *APIGWProxyWorkflowBuilder {
 // adding pre and post handler actions with fluent API.
 type APIGWPrePostHandlerActionBuilder struct {
 	*APIGWProxyWorkflowBuilder
-	handler *handlerData
+	*routeSettings
 }
 
 // WithPreActions adds the pre actions to the previously added handler.
 func (b *APIGWPrePostHandlerActionBuilder) WithPreActions(actions ...Action) *APIGWPrePostHandlerActionBuilder {
-	b.handler.preActions = append(b.handler.preActions, actions...)
+	b.addPreActions(actions)
 	return b
 }
 
 // WithPostActions adds the post actions to the previously added handler.
 func (b *APIGWPrePostHandlerActionBuilder) WithPostActions(actions ...Action) *APIGWPrePostHandlerActionBuilder {
-	b.handler.postActions = append(b.handler.postActions, actions...)
+	b.addPostActions(actions)
+	return b
+}
+
+// WithDecodingOptions sets the options of the JSON request bodies decoding
+// of the previously added handler. The options override the workflow options.
+func (b *APIGWPrePostHandlerActionBuilder) WithDecodingOptions(opts DecodingOptions) *APIGWPrePostHandlerActionBuilder {
+	b.setDecodingOptions(opts)
+	return b
+}
+
+// WithMaxBodySize sets the max size of the request body of the previously
+// added handler. The size overrides the workflow max body size.
+func (b *APIGWPrePostHandlerActionBuilder) WithMaxBodySize(size int) *APIGWPrePostHandlerActionBuilder {
+	b.setMaxBodySize(size)
+	return b
+}
+
+// WithHeader adds condition to the previously added handler route, so the route
+// handles only requests with the provided header value. The routes with more
+// conditions are preferred over the routes with the same method and path and
+// the routes with the same number of conditions are tried in the order in
+// which they are added.
+func (b *APIGWPrePostHandlerActionBuilder) WithHeader(name, value string) *APIGWPrePostHandlerActionBuilder {
+	b.addHeaderCondition(name, value)
+	return b
+}
+
+// WithHeaderPattern adds condition to the previously added handler route, so the
+// route handles only requests with header value which matches the pattern.
+func (b *APIGWPrePostHandlerActionBuilder) WithHeaderPattern(name, pattern string) *APIGWPrePostHandlerActionBuilder {
+	b.addHeaderPatternCondition(name, pattern)
+	return b
+}
+
+// WithHost adds condition to the previously added handler route, so the route
+// handles only requests with the provided Host header.
+func (b *APIGWPrePostHandlerActionBuilder) WithHost(host string) *APIGWPrePostHandlerActionBuilder {
+	b.addHostCondition(host)
+	return b
+}
+
+// WithQuery adds condition to the previously added handler route, so the route
+// handles only requests with the provided query parameter value.
+func (b *APIGWPrePostHandlerActionBuilder) WithQuery(name, value string) *APIGWPrePostHandlerActionBuilder {
+	b.addQueryCondition(name, value)
+	return b
+}
+
+// APIGWNotFoundHandlerActionBuilder is the builder which enables adding pre
+// and post actions to the not found handler with fluent API. The not found
+// handler has no route, so it can not have route conditions.
+type APIGWNotFoundHandlerActionBuilder struct {
+	*APIGWProxyWorkflowBuilder
+	*routeSettings
+}
+
+// WithPreActions adds the pre actions to the not found handler.
+func (b *APIGWNotFoundHandlerActionBuilder) WithPreActions(actions ...Action) *APIGWNotFoundHandlerActionBuilder {
+	b.addPreActions(actions)
 	return b
 }
 
-func (b *APIGWProxyWorkflowBuilder) isParameterizedPath(path string) bool {
-	return len(parameterizedPathRegExp.FindStringSubmatchIndex(path)) > 0
+// WithPostActions adds the post actions to the not found handler.
+func (b *APIGWNotFoundHandlerActionBuilder) WithPostActions(actions ...Action) *APIGWNotFoundHandlerActionBuilder {
+	b.addPostActions(actions)
+	return b
 }
 
-func newAPIGWPrePostHandlerActionBuilder(b *APIGWProxyWorkflowBuilder, handler *handlerData) *APIGWPrePostHandlerActionBuilder {
-	return &APIGWPrePostHandlerActionBuilder{APIGWProxyWorkflowBuilder: b, handler: handler}
+// WithDecodingOptions sets the options of the JSON request bodies decoding
+// of the not found handler. The options override the workflow options.
+func (b *APIGWNotFoundHandlerActionBuilder) WithDecodingOptions(opts DecodingOptions) *APIGWNotFoundHandlerActionBuilder {
+	b.setDecodingOptions(opts)
+	return b
+}
+
+// WithMaxBodySize sets the max size of the request body of the not found
+// handler. The size overrides the workflow max body size.
+func (b *APIGWNotFoundHandlerActionBuilder) WithMaxBodySize(size int) *APIGWNotFoundHandlerActionBuilder {
+	b.setMaxBodySize(size)
+	return b
+}
+
+func (b *APIGWProxyWorkflowBuilder) addRoute(httpMethod, path string, handler interface{}, group *APIGWProxyRouteGroup) *route {
+	hData := &handlerData{handler: handler, preActions: []Action{}, postActions: []Action{}}
+	rt := &route{method: httpMethod, path: path, hData: hData, group: group}
+	if err := validateHandler(handler); err != nil {
+		rt.err = fmt.Errorf("invalid handler for route %s %s: %s", strings.ToUpper(httpMethod), path, err)
+	}
+
+	b.routes = append(b.routes, rt)
+	return rt
+}
+
+// buildRouter creates the workflow router from the registered routes. The
+// route groups actions are added to the route handlers here, so the actions
+// added to the groups after the routes are applied too. The validation
+// rules of the handler inputs are checked with the provided validator.
+func (b *APIGWProxyWorkflowBuilder) buildRouter(v *validator) (*router, Error) {
+	r := newRouter()
+	errs := append([]string{}, b.errs...)
+	for _, rt := range b.routes {
+		if rt.err != nil {
+			errs = append(errs, rt.err.Error())
+			continue
+		}
+
+		if rt.hData.mount == nil {
+			if err := checkHandlerInput(rt.hData.handler, v); err != nil {
+				errs = append(errs, fmt.Sprintf("invalid handler for route %s %s: %s", strings.ToUpper(rt.method), rt.path, err))
+				continue
+			}
+		}
+
+		hData := &handlerData{
+			handler:         rt.hData.handler,
+			typed:           rt.hData.typed,
+			preActions:      append(rt.group.getPreActions(), rt.hData.preActions...),
+			postActions:     append(append([]Action{}, rt.hData.postActions...), rt.group.getPostActions()...),
+			mount:           rt.hData.mount,
+			decodingOptions: rt.hData.decodingOptions,
+			maxBodySize:     rt.hData.maxBodySize,
+			decoders:        rt.hData.decoders,
+		}
+		path := b.pathNormalization.normalizeTemplate(rt.path)
+		err := r.add(&route{method: rt.method, path: path, conditions: rt.conditions, hData: hData})
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if b.notFound.err != nil {
+		errs = append(errs, b.notFound.err.Error())
+	}
+
+	if len(errs) > 0 {
+		return nil, newErrorWithMessage("invalid routes: %s", strings.Join(errs, "; "))
+	}
+
+	return r, nil
+}
+
+func newAPIGWPrePostHandlerActionBuilder(b *APIGWProxyWorkflowBuilder, rt *route) *APIGWPrePostHandlerActionBuilder {
+	return &APIGWPrePostHandlerActionBuilder{APIGWProxyWorkflowBuilder: b, routeSettings: newRouteSettings(rt)}
 }
 
 type handlerData struct {
-	handler     interface{}
+	handler interface{}
+	// typed is set for the handlers registered with the typed
+	// handler functions.
+	typed       *typedHandler
 	preActions  []Action
 	postActions []Action
+	// mount is set if the handler is route of mounted workflow.
+	mount *mountData
+	// The route specific request body decoding settings.
+	decodingOptions *DecodingOptions
+	maxBodySize     int
+	// decoders are the decoders of the mounted workflow.
+	decoders map[string]Decoder
+}
+
+// typedHandler decodes the request directly in the typed handler input
+// and invokes the handler without reflection.
+type typedHandler struct {
+	inputType reflect.Type
+	// newInput returns pointer to new input in which the request is
+	// decoded. The pointer inputs are returned as they are.
+	newInput func() interface{}
+	// invoke invokes the handler with the input returned by newInput.
+	invoke func(c Context, input interface{}) (interface{}, error)
+}
+
+// getInputType returns the type of the handler input parameter or nil if
+// the handler has no input.
+func (h *handlerData) getInputType() reflect.Type {
+	if h.typed != nil {
+		return h.typed.inputType
+	}
+
+	hType := reflect.TypeOf(h.handler)
+	if hType.NumIn() < 2 {
+		return nil
+	}
+
+	return hType.In(1)
 }
 
-type parameterizedHandlerData struct {
-	hData        *handlerData
-	pathRegExp   *regexp.Regexp
-	originalPath string
-	method       string
+type mountData struct {
+	workflow *BaseWorkflow
+	hData    *handlerData
 }
@@ -0,0 +1,185 @@
+package workflow
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// APIGWProxyRouteGroup is the builder of routes with common path prefix
+// and common pre and post actions.
+type APIGWProxyRouteGroup struct {
+	builder     *APIGWProxyWorkflowBuilder
+	parent      *APIGWProxyRouteGroup
+	prefix      string
+	preActions  []Action
+	postActions []Action
+}
+
+// AddGetHandler adds the provided handler to the specified path and GET HTTP method.
+func (g *APIGWProxyRouteGroup) AddGetHandler(path string, handler interface{}) *APIGWProxyRouteGroupHandlerActionBuilder {
+	return g.AddMethodHandler(http.MethodGet, path, handler)
+}
+
+// AddPostHandler adds the provided handler to the specified path and POST HTTP method.
+func (g *APIGWProxyRouteGroup) AddPostHandler(path string, handler interface{}) *APIGWProxyRouteGroupHandlerActionBuilder {
+	return g.AddMethodHandler(http.MethodPost, path, handler)
+}
+
+// AddPutHandler adds the provided handler to the specified path and PUT HTTP method.
+func (g *APIGWProxyRouteGroup) AddPutHandler(path string, handler interface{}) *APIGWProxyRouteGroupHandlerActionBuilder {
+	return g.AddMethodHandler(http.MethodPut, path, handler)
+}
+
+// AddDeleteHandler adds the provided handler to the specified path and DELETE HTTP method.
+func (g *APIGWProxyRouteGroup) AddDeleteHandler(path string, handler interface{}) *APIGWProxyRouteGroupHandlerActionBuilder {
+	return g.AddMethodHandler(http.MethodDelete, path, handler)
+}
+
+// AddHeadHandler adds the provided handler to the specified path and HEAD HTTP method.
+func (g *APIGWProxyRouteGroup) AddHeadHandler(path string, handler interface{}) *APIGWProxyRouteGroupHandlerActionBuilder {
+	return g.AddMethodHandler(http.MethodHead, path, handler)
+}
+
+// AddOptionsHandler adds the provided handler to the specified path and OPTIONS HTTP method.
+func (g *APIGWProxyRouteGroup) AddOptionsHandler(path string, handler interface{}) *APIGWProxyRouteGroupHandlerActionBuilder {
+	return g.AddMethodHandler(http.MethodOptions, path, handler)
+}
+
+// AddMethodHandler adds the provided handler to the group prefix joined with the
+// specified path with the provided HTTP method.
+func (g *APIGWProxyRouteGroup) AddMethodHandler(httpMethod, path string, handler interface{}) *APIGWProxyRouteGroupHandlerActionBuilder {
+	rt := g.builder.addRoute(httpMethod, joinPath(g.getPrefix(), path), handler, g)
+	return newAPIGWProxyRouteGroupHandlerActionBuilder(g, rt)
+}
+
+// AddPreActions adds Pre Actions to all routes in the group. The group
+// pre actions are executed after the workflow pre actions and before
+// the handler pre actions.
+func (g *APIGWProxyRouteGroup) AddPreActions(actions ...Action) *APIGWProxyRouteGroup {
+	g.preActions = append(g.preActions, actions...)
+	return g
+}
+
+// AddPostActions adds Post Actions to all routes in the group. The group
+// post actions are executed after the handler post actions and before
+// the workflow post actions.
+func (g *APIGWProxyRouteGroup) AddPostActions(actions ...Action) *APIGWProxyRouteGroup {
+	g.postActions = append(g.postActions, actions...)
+	return g
+}
+
+// Group creates nested route group. The nested group inherits the prefix
+// and the actions of the current group.
+func (g *APIGWProxyRouteGroup) Group(prefix string) *APIGWProxyRouteGroup {
+	return newAPIGWProxyRouteGroup(g.builder, g, prefix)
+}
+
+func (g *APIGWProxyRouteGroup) getPrefix() string {
+	if g == nil {
+		return ""
+	}
+
+	return joinPath(g.parent.getPrefix(), g.prefix)
+}
+
+// getPreActions returns the pre actions of the group and its parents
+// starting from the outermost group.
+func (g *APIGWProxyRouteGroup) getPreActions() []Action {
+	if g == nil {
+		return []Action{}
+	}
+
+	return append(g.parent.getPreActions(), g.preActions...)
+}
+
+// getPostActions returns the post actions of the group and its parents
+// starting from the innermost group.
+func (g *APIGWProxyRouteGroup) getPostActions() []Action {
+	if g == nil {
+		return []Action{}
+	}
+
+	return append(append([]Action{}, g.postActions...), g.parent.getPostActions()...)
+}
+
+func newAPIGWProxyRouteGroup(b *APIGWProxyWorkflowBuilder, parent *APIGWProxyRouteGroup, prefix string) *APIGWProxyRouteGroup {
+	if err := validatePathPrefix(prefix); err != nil {
+		b.errs = append(b.errs, fmt.Sprintf("invalid route group: %s", err))
+	}
+
+	return &APIGWProxyRouteGroup{
+		builder:     b,
+		parent:      parent,
+		prefix:      prefix,
+		preActions:  []Action{},
+		postActions: []Action{},
+	}
+}
+
+// APIGWProxyRouteGroupHandlerActionBuilder is the builder which enables
+// adding pre and post handler actions to the group routes with fluent API.
+type APIGWProxyRouteGroupHandlerActionBuilder struct {
+	*APIGWProxyRouteGroup
+	*routeSettings
+}
+
+// WithPreActions adds the pre actions to the previously added handler.
+func (b *APIGWProxyRouteGroupHandlerActionBuilder) WithPreActions(actions ...Action) *APIGWProxyRouteGroupHandlerActionBuilder {
+	b.addPreActions(actions)
+	return b
+}
+
+// WithPostActions adds the post actions to the previously added handler.
+func (b *APIGWProxyRouteGroupHandlerActionBuilder) WithPostActions(actions ...Action) *APIGWProxyRouteGroupHandlerActionBuilder {
+	b.addPostActions(actions)
+	return b
+}
+
+// WithDecodingOptions sets the options of the JSON request bodies decoding
+// of the previously added handler. The options override the workflow options.
+func (b *APIGWProxyRouteGroupHandlerActionBuilder) WithDecodingOptions(opts DecodingOptions) *APIGWProxyRouteGroupHandlerActionBuilder {
+	b.setDecodingOptions(opts)
+	return b
+}
+
+// WithMaxBodySize sets the max size of the request body of the previously
+// added handler. The size overrides the workflow max body size.
+func (b *APIGWProxyRouteGroupHandlerActionBuilder) WithMaxBodySize(size int) *APIGWProxyRouteGroupHandlerActionBuilder {
+	b.setMaxBodySize(size)
+	return b
+}
+
+// WithHeader adds condition to the previously added handler route, so the route
+// handles only requests with the provided header value. The routes with more
+// conditions are preferred over the routes with the same method and path and
+// the routes with the same number of conditions are tried in the order in
+// which they are added.
+func (b *APIGWProxyRouteGroupHandlerActionBuilder) WithHeader(name, value string) *APIGWProxyRouteGroupHandlerActionBuilder {
+	b.addHeaderCondition(name, value)
+	return b
+}
+
+// WithHeaderPattern adds condition to the previously added handler route, so the
+// route handles only requests with header value which matches the pattern.
+func (b *APIGWProxyRouteGroupHandlerActionBuilder) WithHeaderPattern(name, pattern string) *APIGWProxyRouteGroupHandlerActionBuilder {
+	b.addHeaderPatternCondition(name, pattern)
+	return b
+}
+
+// WithHost adds condition to the previously added handler route, so the route
+// handles only requests with the provided Host header.
+func (b *APIGWProxyRouteGroupHandlerActionBuilder) WithHost(host string) *APIGWProxyRouteGroupHandlerActionBuilder {
+	b.addHostCondition(host)
+	return b
+}
+
+// WithQuery adds condition to the previously added handler route, so the route
+// handles only requests with the provided query parameter value.
+func (b *APIGWProxyRouteGroupHandlerActionBuilder) WithQuery(name, value string) *APIGWProxyRouteGroupHandlerActionBuilder {
+	b.addQueryCondition(name, value)
+	return b
+}
+
+func newAPIGWProxyRouteGroupHandlerActionBuilder(g *APIGWProxyRouteGroup, rt *route) *APIGWProxyRouteGroupHandlerActionBuilder {
+	return &APIGWProxyRouteGroupHandlerActionBuilder{APIGWProxyRouteGroup: g, routeSettings: newRouteSettings(rt)}
+}
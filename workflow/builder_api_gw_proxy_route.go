@@ -0,0 +1,49 @@
+package workflow
+
+// routeSettings sets the route specific settings of the previously added
+// handler. It is embedded in the handler action builders, which wrap its
+// methods to return themselves, so the routes can be added with fluent API.
+type routeSettings struct {
+	handler *handlerData
+	route   *route
+}
+
+func (s *routeSettings) addPreActions(actions []Action) {
+	s.handler.preActions = append(s.handler.preActions, actions...)
+}
+
+func (s *routeSettings) addPostActions(actions []Action) {
+	s.handler.postActions = append(s.handler.postActions, actions...)
+}
+
+func (s *routeSettings) setDecodingOptions(opts DecodingOptions) {
+	s.handler.decodingOptions = &opts
+}
+
+func (s *routeSettings) setMaxBodySize(size int) {
+	s.handler.maxBodySize = size
+}
+
+func (s *routeSettings) addHeaderCondition(name, value string) {
+	s.route.addCondition(newRouteCondition(headerCondition, name, value), nil)
+}
+
+func (s *routeSettings) addHeaderPatternCondition(name, pattern string) {
+	s.route.addCondition(newRouteRegExpCondition(headerCondition, name, pattern))
+}
+
+func (s *routeSettings) addHostCondition(host string) {
+	s.route.addCondition(newRouteCondition(hostCondition, "", host), nil)
+}
+
+func (s *routeSettings) addQueryCondition(name, value string) {
+	s.route.addCondition(newRouteCondition(queryCondition, name, value), nil)
+}
+
+func (s *routeSettings) getHandlerData() *handlerData {
+	return s.handler
+}
+
+func newRouteSettings(rt *route) *routeSettings {
+	return &routeSettings{handler: rt.hData, route: rt}
+}
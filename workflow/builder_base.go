@@ -5,6 +5,7 @@ type BaseWorkflowBuilder struct {
 	bootstrap   Bootstrap
 	preActions  []Action
 	postActions []Action
+	rules       map[string]ValidationRule
 }
 
 // SetBootstrap sets the bootstrap function to the workflow.
@@ -25,9 +26,22 @@ func (b *BaseWorkflowBuilder) AddPostActions(actions ...Action) *BaseWorkflowBui
 	return b
 }
 
+// AddValidationRule adds validation rule which can be used in the validate
+// tags of the handler inputs. The rule replaces the built-in rule with the
+// same name.
+func (b *BaseWorkflowBuilder) AddValidationRule(name string, rule ValidationRule) *BaseWorkflowBuilder {
+	b.rules[name] = rule
+	return b
+}
+
 // Build creates the Base workflow.
 func (b *BaseWorkflowBuilder) Build() *BaseWorkflow {
-	return &BaseWorkflow{bootstrap: b.bootstrap, preActions: b.preActions, postActions: b.postActions}
+	return &BaseWorkflow{
+		bootstrap:   b.bootstrap,
+		preActions:  b.preActions,
+		postActions: b.postActions,
+		validator:   newValidator(b.rules),
+	}
 }
 
 // NewBaseWorkflowBuilder creates new Base workflow builder.
@@ -35,5 +49,6 @@ func NewBaseWorkflowBuilder() *BaseWorkflowBuilder {
 	return &BaseWorkflowBuilder{
 		preActions:  []Action{},
 		postActions: []Action{},
+		rules:       make(map[string]ValidationRule),
 	}
 }
@@ -2,6 +2,7 @@ package workflow
 
 import (
 	"context"
+	"net/http"
 	"reflect"
 )
 
@@ -14,9 +15,17 @@ type Context interface {
 	GetRawResponse(out interface{}) Error
 	GetHandlerError() error
 	GetRequest() interface{}
+	GetPathParameters() map[string]string
+	GetPathParameter(name string) string
+	GetPath() string
+	GetOriginalPath() string
+	GetCookies() []*http.Cookie
+	GetCookie(name string) (*http.Cookie, error)
 	SetResponse(interface{}) Context
 	SetRawResponse(interface{}) Context
 	SetResponseStatusCode(int) Context
+	SetResponseHeader(key, value string) Context
+	AddResponseCookie(cookie *http.Cookie) Context
 }
 
 type lambdaCtx struct {
@@ -25,11 +34,20 @@ type lambdaCtx struct {
 	lambdaEvent   interface{}
 	injector      Injector
 	req           *reflect.Value
+	pathParams    map[string]string
+	path          string
+	originalPath  string
+	// cookieHeaders are the Cookie header values of the request.
+	cookieHeaders []string
+	// input is the pointer to the typed handler input.
+	input interface{}
 
 	// Set by the user
 	response           interface{}
 	rawResponse        interface{}
 	responseStatusCode int
+	responseHeaders    map[string]string
+	responseCookies    []*http.Cookie
 
 	handlerErr error
 }
@@ -49,6 +67,23 @@ func (c *lambdaCtx) SetResponseStatusCode(code int) Context {
 	return c
 }
 
+func (c *lambdaCtx) SetResponseHeader(key, value string) Context {
+	if c.responseHeaders == nil {
+		c.responseHeaders = make(map[string]string)
+	}
+
+	c.responseHeaders[key] = value
+	return c
+}
+
+// AddResponseCookie adds the cookie to the response. The cookies are
+// rendered as Set-Cookie headers of the response. The cookies with
+// invalid names are not rendered.
+func (c *lambdaCtx) AddResponseCookie(cookie *http.Cookie) Context {
+	c.responseCookies = append(c.responseCookies, cookie)
+	return c
+}
+
 func (c *lambdaCtx) GetLambdaContext() context.Context {
 	return c.lambdaContext
 }
@@ -85,3 +120,85 @@ func (c *lambdaCtx) GetRequestObject(out interface{}) Error {
 func (c *lambdaCtx) GetRequest() interface{} {
 	return c.req.Interface()
 }
+
+func (c *lambdaCtx) GetPathParameters() map[string]string {
+	res := make(map[string]string, len(c.pathParams))
+	for k, v := range c.pathParams {
+		res[k] = v
+	}
+
+	return res
+}
+
+func (c *lambdaCtx) GetPathParameter(name string) string {
+	return c.pathParams[name]
+}
+
+func (c *lambdaCtx) GetPath() string {
+	return c.path
+}
+
+func (c *lambdaCtx) GetOriginalPath() string {
+	return c.originalPath
+}
+
+// GetCookies returns the cookies of the request Cookie headers.
+func (c *lambdaCtx) GetCookies() []*http.Cookie {
+	if len(c.cookieHeaders) == 0 {
+		return []*http.Cookie{}
+	}
+
+	req := http.Request{Header: http.Header{"Cookie": c.cookieHeaders}}
+	return req.Cookies()
+}
+
+// GetCookie returns the request cookie with the provided name or
+// http.ErrNoCookie if there is no such cookie.
+func (c *lambdaCtx) GetCookie(name string) (*http.Cookie, error) {
+	for _, cookie := range c.GetCookies() {
+		if cookie.Name == name {
+			return cookie, nil
+		}
+	}
+
+	return nil, http.ErrNoCookie
+}
+
+// setResponseFrom sets the response and the handler error of the provided
+// context to the current context.
+func (c *lambdaCtx) setResponseFrom(from *lambdaCtx) {
+	c.response = from.response
+	c.rawResponse = from.rawResponse
+	c.responseStatusCode = from.responseStatusCode
+	for k, v := range from.responseHeaders {
+		c.SetResponseHeader(k, v)
+	}
+
+	c.responseCookies = append(c.responseCookies, from.responseCookies...)
+	c.handlerErr = from.handlerErr
+}
+
+func withPath(path, originalPath string) contextOption {
+	return func(c *lambdaCtx) {
+		c.path = path
+		c.originalPath = originalPath
+	}
+}
+
+func withPathParameters(params map[string]string) contextOption {
+	return func(c *lambdaCtx) {
+		c.pathParams = params
+	}
+}
+
+func withCookieHeaders(headers []string) contextOption {
+	return func(c *lambdaCtx) {
+		c.cookieHeaders = headers
+	}
+}
+
+func withHandlerInput(input interface{}) contextOption {
+	return func(c *lambdaCtx) {
+		c.input = input
+	}
+}
@@ -0,0 +1,241 @@
+package workflow
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// The media types of the request bodies decoded by default.
+const (
+	JSONMediaType      = "application/json"
+	FormMediaType      = "application/x-www-form-urlencoded"
+	MultipartMediaType = "multipart/form-data"
+	XMLMediaType       = "application/xml"
+	TextXMLMediaType   = "text/xml"
+)
+
+const (
+	formTag = "form"
+	// maxMultipartMemory is the size of the multipart form files which
+	// are kept in memory, the larger files are stored in temporary files.
+	maxMultipartMemory = 32 << 20
+)
+
+var (
+	fileHeaderType      = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeaderSliceType = reflect.TypeOf([]*multipart.FileHeader{})
+	readerType          = reflect.TypeOf((*io.Reader)(nil)).Elem()
+)
+
+// Decoder decodes the request body to the handler input. The params are
+// the parameters of the request Content-Type like charset and boundary.
+// The decoder may return BindingError to report the invalid fields.
+type Decoder func(body []byte, params map[string]string, out interface{}) error
+
+// DecodingOptions configures the decoding of the JSON request bodies. The
+// JSON bodies with data after the top-level value are always rejected.
+type DecodingOptions struct {
+	// DisallowUnknownFields rejects the bodies with fields which are
+	// not in the handler input struct.
+	DisallowUnknownFields bool
+	// UseNumber decodes the numbers in interface{} values as json.Number
+	// instead of float64.
+	UseNumber bool
+}
+
+func getDefaultDecoders() map[string]Decoder {
+	return map[string]Decoder{
+		JSONMediaType:      decodeJSON,
+		FormMediaType:      decodeForm,
+		MultipartMediaType: decodeMultipart,
+		XMLMediaType:       decodeXML,
+		TextXMLMediaType:   decodeXML,
+	}
+}
+
+// getDecoder returns the decoder for the Content-Type. The JSON decoder is
+// used if there is no Content-Type and the decoders of the JSON and XML
+// media types are used for the media types with +json and +xml suffix.
+func getDecoder(decoders map[string]Decoder, contentType string) (d Decoder, mediaType string, params map[string]string, err error) {
+	if len(contentType) == 0 {
+		return decoders[JSONMediaType], JSONMediaType, nil, nil
+	}
+
+	mediaType, params, err = mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, contentType, nil, newUnsupportedMediaTypeError(contentType)
+	}
+
+	if d, ok := decoders[mediaType]; ok {
+		return d, mediaType, params, nil
+	}
+
+	if strings.HasSuffix(mediaType, "+json") {
+		return decoders[JSONMediaType], mediaType, params, nil
+	}
+
+	if strings.HasSuffix(mediaType, "+xml") {
+		return decoders[XMLMediaType], mediaType, params, nil
+	}
+
+	return nil, mediaType, nil, newUnsupportedMediaTypeError(mediaType)
+}
+
+func decodeJSON(body []byte, params map[string]string, out interface{}) error {
+	return json.Unmarshal(body, out)
+}
+
+// newJSONDecoder creates JSON decoder with the provided options.
+func newJSONDecoder(opts DecodingOptions) Decoder {
+	return func(body []byte, params map[string]string, out interface{}) error {
+		d := json.NewDecoder(bytes.NewReader(body))
+		if opts.DisallowUnknownFields {
+			d.DisallowUnknownFields()
+		}
+
+		if opts.UseNumber {
+			d.UseNumber()
+		}
+
+		if err := d.Decode(out); err != nil {
+			return err
+		}
+
+		if _, err := d.Token(); err != io.EOF {
+			return fmt.Errorf("invalid data after top-level JSON value")
+		}
+
+		return nil
+	}
+}
+
+func decodeXML(body []byte, params map[string]string, out interface{}) error {
+	return xml.Unmarshal(body, out)
+}
+
+func decodeForm(body []byte, params map[string]string, out interface{}) error {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return err
+	}
+
+	return bindForm(values, nil, out)
+}
+
+// decodeMultipart decodes the multipart form values like the url encoded
+// form values. The files are set to the fields with *multipart.FileHeader
+// and []*multipart.FileHeader types or opened and set to io.Reader fields.
+func decodeMultipart(body []byte, params map[string]string, out interface{}) error {
+	boundary, ok := params["boundary"]
+	if !ok {
+		return fmt.Errorf("missing multipart boundary")
+	}
+
+	form, err := multipart.NewReader(bytes.NewReader(body), boundary).ReadForm(maxMultipartMemory)
+	if err != nil {
+		return err
+	}
+
+	return bindForm(form.Value, form.File, out)
+}
+
+// bindForm sets the form values to map or to struct fields by their form
+// tags. The json tag names and the field names are used for the fields
+// without form tag.
+func bindForm(values map[string][]string, files map[string][]*multipart.FileHeader, out interface{}) error {
+	v := reflect.ValueOf(out).Elem()
+	switch {
+	case v.Type() == reflect.TypeOf(map[string]string{}):
+		m := make(map[string]string, len(values))
+		for k, vs := range values {
+			m[k] = vs[len(vs)-1]
+		}
+
+		v.Set(reflect.ValueOf(m))
+	case v.Type() == reflect.TypeOf(map[string][]string{}), v.Type() == reflect.TypeOf(url.Values{}):
+		v.Set(reflect.ValueOf(values).Convert(v.Type()))
+	case v.Kind() == reflect.Struct:
+		errs := []FieldError{}
+		bindFormStruct(v, "", values, files, &errs)
+		if len(errs) > 0 {
+			return &BindingError{Fields: errs}
+		}
+	default:
+		return fmt.Errorf("cannot decode form to %s", v.Type())
+	}
+
+	return nil
+}
+
+func bindFormStruct(v reflect.Value, prefix string, values map[string][]string, files map[string][]*multipart.FileHeader, errs *[]FieldError) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if isEmbeddedStruct(f) {
+			bindFormStruct(v.Field(i), prefix, values, files, errs)
+			continue
+		}
+
+		name := getFormName(f)
+		if len(f.PkgPath) > 0 || name == "-" {
+			continue
+		}
+
+		field := joinFieldPath(prefix, f.Name)
+		if isFileField(f.Type) {
+			if err := setFileField(v.Field(i), files[name]); err != nil {
+				*errs = append(*errs, FieldError{Field: field, Source: bodyTag, Name: name, Message: err.Error()})
+			}
+
+			continue
+		}
+
+		if vs, ok := values[name]; ok && len(vs) > 0 {
+			setFieldValues(v.Field(i), field, bodyTag, name, vs, false, errs)
+		}
+	}
+}
+
+func getFormName(f reflect.StructField) string {
+	for _, tag := range []string{formTag, "json"} {
+		if name := strings.Split(f.Tag.Get(tag), ",")[0]; len(name) > 0 {
+			return name
+		}
+	}
+
+	return f.Name
+}
+
+func isFileField(t reflect.Type) bool {
+	return t == fileHeaderType || t == fileHeaderSliceType || t == readerType
+}
+
+func setFileField(v reflect.Value, files []*multipart.FileHeader) error {
+	if len(files) == 0 {
+		return nil
+	}
+
+	switch v.Type() {
+	case fileHeaderType:
+		v.Set(reflect.ValueOf(files[0]))
+	case fileHeaderSliceType:
+		v.Set(reflect.ValueOf(files))
+	case readerType:
+		file, err := files[0].Open()
+		if err != nil {
+			return err
+		}
+
+		v.Set(reflect.ValueOf(file))
+	}
+
+	return nil
+}
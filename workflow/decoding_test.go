@@ -0,0 +1,370 @@
+package workflow
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type decodingFormReq struct {
+	Name  string   `form:"name"`
+	Count int      `json:"count"`
+	Tags  []string `form:"tag"`
+}
+
+type decodingMultipartReq struct {
+	Name   string                  `form:"name"`
+	File   io.Reader               `form:"file"`
+	Header *multipart.FileHeader   `form:"file"`
+	Files  []*multipart.FileHeader `form:"files"`
+}
+
+type decodingXMLReq struct {
+	Name string `xml:"name"`
+}
+
+func TestDecoding(t *testing.T) {
+	Convey("Request body decoding", t, func() {
+		getRequest := func(contentType, body string) events.APIGatewayProxyRequest {
+			return events.APIGatewayProxyRequest{
+				HTTPMethod: http.MethodPost,
+				Path:       "/",
+				Headers:    map[string]string{"content-type": contentType},
+				Body:       body,
+			}
+		}
+
+		Convey("Should decode url encoded form.", func() {
+			var input decodingFormReq
+			h := NewAPIGWProxyWorkflowBuilder().
+				AddPostHandler("/", func(c Context, req decodingFormReq) error {
+					input = req
+					return nil
+				}).
+				Build().
+				GetLambdaHandler()
+
+			_, err := h(nil, getRequest(FormMediaType, "name=test&count=5&tag=a&tag=b"))
+			So(err, ShouldBeNil)
+			So(input, ShouldResemble, decodingFormReq{Name: "test", Count: 5, Tags: []string{"a", "b"}})
+		})
+
+		Convey("Should decode multipart form with files.", func() {
+			body := new(bytes.Buffer)
+			mw := multipart.NewWriter(body)
+			So(mw.WriteField("name", "test"), ShouldBeNil)
+			fw, _ := mw.CreateFormFile("file", "a.txt")
+			fw.Write([]byte("file a"))
+			fw, _ = mw.CreateFormFile("files", "b.txt")
+			fw.Write([]byte("file b"))
+			fw, _ = mw.CreateFormFile("files", "c.txt")
+			fw.Write([]byte("file c"))
+			So(mw.Close(), ShouldBeNil)
+
+			var input decodingMultipartReq
+			h := NewAPIGWProxyWorkflowBuilder().
+				AddPostHandler("/", func(c Context, req decodingMultipartReq) error {
+					input = req
+					return nil
+				}).
+				Build().
+				GetLambdaHandler()
+
+			_, err := h(nil, getRequest(mw.FormDataContentType(), body.String()))
+			So(err, ShouldBeNil)
+			So(input.Name, ShouldEqual, "test")
+			So(input.Header.Filename, ShouldEqual, "a.txt")
+			So(len(input.Files), ShouldEqual, 2)
+			So(input.Files[1].Filename, ShouldEqual, "c.txt")
+
+			content, err := ioutil.ReadAll(input.File)
+			So(err, ShouldBeNil)
+			So(string(content), ShouldEqual, "file a")
+		})
+
+		Convey("Should decode XML.", func() {
+			var input decodingXMLReq
+			h := NewAPIGWProxyWorkflowBuilder().
+				AddPostHandler("/", func(c Context, req *decodingXMLReq) error {
+					input = *req
+					return nil
+				}).
+				Build().
+				GetLambdaHandler()
+
+			_, err := h(nil, getRequest("application/atom+xml", "<req><name>test</name></req>"))
+			So(err, ShouldBeNil)
+			So(input.Name, ShouldEqual, "test")
+		})
+
+		Convey("Should use the registered decoders.", func() {
+			var input [][]string
+			h := NewAPIGWProxyWorkflowBuilder().
+				AddDecoder("text/csv", func(body []byte, params map[string]string, out interface{}) error {
+					records, err := csv.NewReader(bytes.NewReader(body)).ReadAll()
+					if err != nil {
+						return err
+					}
+
+					*(out.(*[][]string)) = records
+					return nil
+				}).
+				AddPostHandler("/", func(c Context, req [][]string) error {
+					input = req
+					return nil
+				}).
+				Build().
+				GetLambdaHandler()
+
+			_, err := h(nil, getRequest("text/csv; charset=utf-8", "a,b\nc,d\n"))
+			So(err, ShouldBeNil)
+			So(input, ShouldResemble, [][]string{{"a", "b"}, {"c", "d"}})
+		})
+
+		Convey("Should set the raw body to string input for body which is not JSON.", func() {
+			var input string
+			h := NewAPIGWProxyWorkflowBuilder().
+				AddPostHandler("/", func(c Context, req string) error {
+					input = req
+					return nil
+				}).
+				Build().
+				GetLambdaHandler()
+
+			_, err := h(nil, getRequest("text/plain", "hello"))
+			So(err, ShouldBeNil)
+			So(input, ShouldEqual, "hello")
+		})
+
+		Convey("Should decode base64 encoded bodies.", func() {
+			var raw []byte
+			var input JSONReq
+			h := NewAPIGWProxyWorkflowBuilder().
+				AddPostHandler("/raw", func(c Context, req []byte) error {
+					raw = req
+					return nil
+				}).
+				AddPostHandler("/json", func(c Context, req JSONReq) error {
+					input = req
+					return nil
+				}).
+				Build().
+				GetLambdaHandler()
+
+			evt := getRequest("image/png", base64.StdEncoding.EncodeToString([]byte{0x89, 0x50, 0x4e, 0x47}))
+			evt.IsBase64Encoded = true
+			evt.Path = "/raw"
+			_, err := h(nil, evt)
+			So(err, ShouldBeNil)
+			So(raw, ShouldResemble, []byte{0x89, 0x50, 0x4e, 0x47})
+
+			evt.Headers = nil
+			evt.Path = "/raw"
+			_, err = h(nil, evt)
+			So(err, ShouldBeNil)
+			So(raw, ShouldResemble, []byte{0x89, 0x50, 0x4e, 0x47})
+
+			evt.Body = base64.StdEncoding.EncodeToString([]byte(`{"message":"test"}`))
+			evt.Path = "/json"
+			_, err = h(nil, evt)
+			So(err, ShouldBeNil)
+			So(input.Message, ShouldEqual, "test")
+
+			evt.Body = "!"
+			res, err := h(nil, evt)
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusBadRequest)
+		})
+
+		Convey("Should set the raw body of any content type to the body field of raw body type.", func() {
+			type uploadReq struct {
+				Name string `query:"name"`
+				Data []byte `body:""`
+			}
+			var input uploadReq
+			h := NewAPIGWProxyWorkflowBuilder().
+				AddPostHandler("/", func(c Context, req uploadReq) error {
+					input = req
+					c.SetResponseStatusCode(http.StatusOK)
+					return nil
+				}).
+				Build().
+				GetLambdaHandler()
+
+			evt := getRequest("image/png", base64.StdEncoding.EncodeToString([]byte{0x89, 0x50, 0x4e, 0x47}))
+			evt.IsBase64Encoded = true
+			evt.QueryStringParameters = map[string]string{"name": "logo.png"}
+			res, err := h(nil, evt)
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusOK)
+			So(input, ShouldResemble, uploadReq{Name: "logo.png", Data: []byte{0x89, 0x50, 0x4e, 0x47}})
+		})
+
+		Convey("Should decode JSON bodies with the workflow and route decoding options.", func() {
+			var input map[string]interface{}
+			b := NewAPIGWProxyWorkflowBuilder().
+				SetDecodingOptions(DecodingOptions{DisallowUnknownFields: true})
+			b.AddPostHandler("/strict", func(c Context, req JSONReq) error {
+				return nil
+			})
+			b.AddPostHandler("/number", func(c Context, req map[string]interface{}) error {
+				input = req
+				return nil
+			}).WithDecodingOptions(DecodingOptions{UseNumber: true})
+			h := b.Build().GetLambdaHandler()
+
+			evt := getRequest(JSONMediaType, `{"message":"test","unknown":1}`)
+			evt.Path = "/strict"
+			res, err := h(nil, evt)
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusBadRequest)
+			So(res.Body, ShouldContainSubstring, `unknown field \"unknown\"`)
+
+			evt.Body = `{"message":"test"} {}`
+			res, err = h(nil, evt)
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusBadRequest)
+
+			evt.Body = `{"message":"test"}`
+			res, err = h(nil, evt)
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, 0)
+
+			evt.Body = `{"count":1,"unknown":1}`
+			evt.Path = "/number"
+			_, err = h(nil, evt)
+			So(err, ShouldBeNil)
+			So(input["count"], ShouldEqual, json.Number("1"))
+		})
+
+		Convey("Should respond with request entity too large before decoding the body.", func() {
+			flow := ""
+			b := NewAPIGWProxyWorkflowBuilder().
+				SetMaxBodySize(10).
+				AddPreActions(func(c Context) error {
+					flow += "wpre"
+					return nil
+				})
+			b.AddPostHandler("/", func(c Context, req JSONReq) error {
+				flow += "handler"
+				return nil
+			})
+			b.AddPostHandler("/large", func(c Context, req JSONReq) error {
+				flow += "handler"
+				return nil
+			}).WithMaxBodySize(100)
+			h := b.Build().GetLambdaHandler()
+
+			evt := getRequest(JSONMediaType, `{"message":"too large"}`)
+			res, err := h(nil, evt)
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusRequestEntityTooLarge)
+			So(flow, ShouldEqual, "wpre")
+
+			flow = ""
+			evt.Path = "/large"
+			res, err = h(nil, evt)
+			So(err, ShouldBeNil)
+			So(flow, ShouldEqual, "wprehandler")
+		})
+
+		Convey("Should compare the decoded size of base64 encoded bodies with the max body size.", func() {
+			h := NewAPIGWProxyWorkflowBuilder().
+				SetMaxBodySize(2).
+				AddPostHandler("/", func(c Context, req []byte) error {
+					c.SetResponseStatusCode(http.StatusOK)
+					return nil
+				}).
+				Build().
+				GetLambdaHandler()
+
+			evt := getRequest("application/octet-stream", base64.StdEncoding.EncodeToString([]byte{1}))
+			evt.IsBase64Encoded = true
+			res, err := h(nil, evt)
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusOK)
+
+			evt.Body = base64.StdEncoding.EncodeToString([]byte{1, 2, 3})
+			res, err = h(nil, evt)
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusRequestEntityTooLarge)
+		})
+
+		Convey("Should use the body decoding settings of the mounted workflows.", func() {
+			var input [][]string
+			child := NewAPIGWProxyWorkflowBuilder().
+				SetMaxBodySize(10).
+				SetDecodingOptions(DecodingOptions{DisallowUnknownFields: true}).
+				AddDecoder("text/csv", func(body []byte, params map[string]string, out interface{}) error {
+					records, err := csv.NewReader(bytes.NewReader(body)).ReadAll()
+					if err != nil {
+						return err
+					}
+
+					*(out.(*[][]string)) = records
+					return nil
+				})
+			child.AddPostHandler("/csv", func(c Context, req [][]string) error {
+				input = req
+				return nil
+			})
+			child.AddPostHandler("/json", func(c Context, req JSONReq) error {
+				return nil
+			})
+			h := NewAPIGWProxyWorkflowBuilder().
+				Mount("/v1", child.Build()).
+				Build().
+				GetLambdaHandler()
+
+			evt := getRequest("text/csv", "a,b\n")
+			evt.Path = "/v1/csv"
+			_, err := h(nil, evt)
+			So(err, ShouldBeNil)
+			So(input, ShouldResemble, [][]string{{"a", "b"}})
+
+			evt.Body = "a,b\nc,d\ne,f\n"
+			res, err := h(nil, evt)
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusRequestEntityTooLarge)
+
+			evt = getRequest(JSONMediaType, `{"x":1}`)
+			evt.Path = "/v1/json"
+			res, err = h(nil, evt)
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusBadRequest)
+		})
+
+		Convey("Should respond with unsupported media type with the workflow actions.", func() {
+			flow := ""
+			h := NewAPIGWProxyWorkflowBuilder().
+				AddPreActions(func(c Context) error {
+					flow += "wpre"
+					return nil
+				}).
+				AddPostHandler("/", func(c Context, req decodingFormReq) error {
+					flow += "handler"
+					return nil
+				}).
+				Build().
+				GetLambdaHandler()
+
+			res, err := h(nil, getRequest("text/csv", "a,b"))
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusUnsupportedMediaType)
+			So(flow, ShouldEqual, "wpre")
+
+			body := ErrorResponse{}
+			So(json.Unmarshal([]byte(res.Body), &body), ShouldBeNil)
+			So(body.Message, ShouldEqual, "unsupported media type text/csv")
+		})
+	})
+}
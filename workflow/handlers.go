@@ -2,7 +2,9 @@ package workflow
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/aws/aws-lambda-go/events"
 )
@@ -10,9 +12,83 @@ import (
 // APIGWProxyHandler is AWS API Gateway handler function.
 type APIGWProxyHandler func(ctx context.Context, evt events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error)
 
-var defaultAPIGWProxyHandler = func(ctx context.Context, evt events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
-	return &events.APIGatewayProxyResponse{StatusCode: http.StatusNotFound}, nil
-}
-
 // APIGWAuthorizerHandler is AWS API Gateway Authorizer handler function.
 type APIGWAuthorizerHandler func(ctx context.Context, evt events.APIGatewayCustomAuthorizerRequest) (*events.APIGatewayCustomAuthorizerResponse, error)
+
+// ErrorResponse is the response body of the API Gateway proxy workflow
+// for invalid requests.
+type ErrorResponse struct {
+	Message string       `json:"message"`
+	Errors  []FieldError `json:"errors,omitempty"`
+}
+
+func defaultNotFoundHandler(c Context) error {
+	c.SetResponseStatusCode(http.StatusNotFound).
+		SetResponse(ErrorResponse{Message: "not found"})
+	return nil
+}
+
+func newMethodNotAllowedHandler(allowedMethods []string) func(Context) error {
+	return func(c Context) error {
+		c.SetResponseHeader("Allow", strings.Join(allowedMethods, ", ")).
+			SetResponseStatusCode(http.StatusMethodNotAllowed).
+			SetResponse(ErrorResponse{Message: "method not allowed"})
+		return nil
+	}
+}
+
+func newOptionsHandler(allowedMethods []string) func(Context) error {
+	return func(c Context) error {
+		c.SetResponseHeader("Allow", strings.Join(allowedMethods, ", ")).
+			SetResponseStatusCode(http.StatusNoContent)
+		return nil
+	}
+}
+
+// requestError is the error of request which can not be handled. The
+// request is responded with the status code of the error.
+type requestError struct {
+	statusCode int
+	message    string
+	fields     []FieldError
+}
+
+func (e *requestError) Error() string {
+	return e.message
+}
+
+func newUnsupportedMediaTypeError(mediaType string) *requestError {
+	return &requestError{statusCode: http.StatusUnsupportedMediaType, message: "unsupported media type " + mediaType}
+}
+
+func newRequestEntityTooLargeError(maxSize int) *requestError {
+	return &requestError{statusCode: http.StatusRequestEntityTooLarge, message: fmt.Sprintf("request body is larger than %d bytes", maxSize)}
+}
+
+// getRequestError returns the request error of the request binding and
+// decoding errors. Returns nil if the error is not request error.
+func getRequestError(err Error) *requestError {
+	switch e := err.OriginalError().(type) {
+	case *requestError:
+		return e
+	case *BindingError:
+		return &requestError{statusCode: http.StatusBadRequest, message: "invalid request", fields: e.Fields}
+	}
+
+	return nil
+}
+
+func newRequestErrorHandler(err *requestError) *handlerData {
+	handler := func(c Context) error {
+		c.SetResponseStatusCode(err.statusCode).
+			SetResponse(ErrorResponse{Message: err.message, Errors: err.fields})
+		return nil
+	}
+
+	return &handlerData{handler: handler, preActions: []Action{}, postActions: []Action{}}
+}
+
+func setValidationErrorResponse(c Context, err *ValidationError) {
+	c.SetResponseStatusCode(http.StatusBadRequest).
+		SetResponse(ErrorResponse{Message: "validation failed", Errors: err.Fields})
+}
@@ -0,0 +1,91 @@
+//go:build go1.21
+
+package workflow
+
+import (
+	"net/http"
+	"reflect"
+)
+
+// TypedHandler is handler with typed request and result. The request is
+// decoded and bound like the request of any other handler and the result
+// is set as response like the result of the handlers added with the
+// builder methods.
+type TypedHandler[Req, Res any] func(c Context, req Req) (Res, error)
+
+// RouteBuilder is implemented by the workflow builder, the route groups
+// and their handler action builders, to which the typed handlers can be
+// added. B is the builder of the added handler actions.
+type RouteBuilder[B HandlerActionBuilder] interface {
+	AddMethodHandler(httpMethod, path string, handler interface{}) B
+}
+
+// HandlerActionBuilder is implemented by the builders of the handler
+// actions returned when handler is added.
+type HandlerActionBuilder interface {
+	getHandlerData() *handlerData
+}
+
+// Get adds the typed handler to the specified path and GET HTTP method:
+//
+//	workflow.Get[GetItemReq, Item](b, "/items/{id}", getItem).
+//		WithPreActions(authorize)
+func Get[Req, Res any, B HandlerActionBuilder](b RouteBuilder[B], path string, handler TypedHandler[Req, Res]) B {
+	return Handle(b, http.MethodGet, path, handler)
+}
+
+// Post adds the typed handler to the specified path and POST HTTP method.
+func Post[Req, Res any, B HandlerActionBuilder](b RouteBuilder[B], path string, handler TypedHandler[Req, Res]) B {
+	return Handle(b, http.MethodPost, path, handler)
+}
+
+// Put adds the typed handler to the specified path and PUT HTTP method.
+func Put[Req, Res any, B HandlerActionBuilder](b RouteBuilder[B], path string, handler TypedHandler[Req, Res]) B {
+	return Handle(b, http.MethodPut, path, handler)
+}
+
+// Delete adds the typed handler to the specified path and DELETE HTTP method.
+func Delete[Req, Res any, B HandlerActionBuilder](b RouteBuilder[B], path string, handler TypedHandler[Req, Res]) B {
+	return Handle(b, http.MethodDelete, path, handler)
+}
+
+// Handle adds the typed handler to the specified path with the provided
+// HTTP method. The request is decoded directly in the handler input and
+// the handler is invoked without reflection.
+func Handle[Req, Res any, B HandlerActionBuilder](b RouteBuilder[B], httpMethod, path string, handler TypedHandler[Req, Res]) B {
+	if handler == nil {
+		return b.AddMethodHandler(httpMethod, path, nil)
+	}
+
+	ab := b.AddMethodHandler(httpMethod, path, handler)
+	ab.getHandlerData().typed = newTypedHandler(handler)
+	return ab
+}
+
+func newTypedHandler[Req, Res any](handler TypedHandler[Req, Res]) *typedHandler {
+	h := &typedHandler{inputType: reflect.TypeOf((*Req)(nil)).Elem()}
+	if h.inputType.Kind() == reflect.Ptr {
+		// The pointed type of the pointer inputs is known only at
+		// run time, so they are created with reflection.
+		elemType := h.inputType.Elem()
+		h.newInput = func() interface{} { return reflect.New(elemType).Interface() }
+		h.invoke = func(c Context, input interface{}) (interface{}, error) {
+			req, _ := input.(Req)
+			return handler(c, req)
+		}
+
+		return h
+	}
+
+	h.newInput = func() interface{} { return new(Req) }
+	h.invoke = func(c Context, input interface{}) (interface{}, error) {
+		var req Req
+		if in, ok := input.(*Req); ok {
+			req = *in
+		}
+
+		return handler(c, req)
+	}
+
+	return h
+}
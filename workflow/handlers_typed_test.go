@@ -0,0 +1,129 @@
+//go:build go1.21
+
+package workflow
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type typedItemReq struct {
+	ID   string `path:"id"`
+	Name string `json:"name" validate:"required"`
+}
+
+func TestTypedHandlers(t *testing.T) {
+	Convey("Typed handlers", t, func() {
+		flow := ""
+		action := func(name string) Action {
+			return func(c Context) error {
+				flow += name
+				return nil
+			}
+		}
+
+		Convey("Should invoke the typed handlers with the workflow, group and handler actions.", func() {
+			b := NewAPIGWProxyWorkflowBuilder().
+				AddPreActions(action("wpre")).
+				AddPostActions(action("wpost"))
+			Post(b, "/items/{id}", func(c Context, req typedItemReq) (typedItemReq, error) {
+				flow += "handler"
+				return req, nil
+			}).WithPreActions(action("hpre"))
+			Get[struct{}, *createdRes](b, "/items", func(c Context, req struct{}) (*createdRes, error) {
+				return nil, fmt.Errorf("test")
+			})
+
+			g := b.Group("/v2").AddPreActions(action("gpre"))
+			Put(g, "/items/{id}", func(c Context, req *typedItemReq) (*createdRes, error) {
+				flow += "handler"
+				return &createdRes{ID: req.ID}, nil
+			}).WithPostActions(action("hpost"))
+
+			w := b.Build()
+			h := w.GetLambdaHandler()
+
+			res, err := h(nil, getAPIGWProxyRequest(http.MethodPost, "/items/1", typedItemReq{Name: "test"}))
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusOK)
+			So(res.Body, ShouldEqual, getStringBody(typedItemReq{ID: "1", Name: "test"}))
+			So(flow, ShouldEqual, "wprehprehandlerwpost")
+
+			flow = ""
+			res, err = h(nil, getAPIGWProxyRequest(http.MethodPut, "/v2/items/2", typedItemReq{Name: "test"}))
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusCreated)
+			So(res.Headers["Location"], ShouldEqual, "/2")
+			So(flow, ShouldEqual, "wpregprehandlerhpostwpost")
+
+			flow = ""
+			res, err = h(nil, getAPIGWProxyRequest(http.MethodPost, "/items/1", typedItemReq{}))
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusBadRequest)
+			So(flow, ShouldEqual, "wprehprewpost")
+
+			_, err = h(nil, getAPIGWProxyRequest(http.MethodGet, "/items", nil))
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "test")
+
+			So(w.Routes()[0].InputType, ShouldEqual, "struct {}")
+			So(w.Routes()[0].OutputType, ShouldEqual, "*workflow.createdRes")
+		})
+
+		Convey("Should invoke the typed handlers of mounted workflows.", func() {
+			child := NewAPIGWProxyWorkflowBuilder()
+			Delete(child, "/items/{id}", func(c Context, req bindingBodyReq) (string, error) {
+				return "deleted " + req.ID, nil
+			})
+
+			w := NewAPIGWProxyWorkflowBuilder().Mount("/v1", child.Build()).Build()
+			res, err := w.GetLambdaHandler()(nil, getAPIGWProxyRequest(http.MethodDelete, "/v1/items/3", nil))
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusOK)
+			So(res.Body, ShouldEqual, `"deleted 3"`)
+		})
+
+		Convey("Should decode the request directly in the typed handler input.", func() {
+			var input *JSONReq
+			var request interface{}
+			b := NewAPIGWProxyWorkflowBuilder().AddPreActions(func(c Context) error {
+				request = c.GetRequest()
+				return nil
+			})
+			Post(b, "/", func(c Context, req *JSONReq) (string, error) {
+				input = req
+				return req.Message, nil
+			})
+
+			h := b.Build().GetLambdaHandler()
+			res, err := h(nil, getAPIGWProxyRequest(http.MethodPost, "/", JSONReq{Message: "test"}))
+			So(err, ShouldBeNil)
+			So(res.Body, ShouldEqual, `"test"`)
+			So(input, ShouldResemble, &JSONReq{Message: "test"})
+			So(request, ShouldEqual, input)
+
+			_, err = h(nil, getAPIGWProxyRequest(http.MethodPost, "/", nil))
+			So(err, ShouldBeNil)
+			So(input, ShouldResemble, &JSONReq{})
+
+			Get(b, "/nil", func(c Context, req struct{}) (*createdRes, error) {
+				return nil, nil
+			})
+			res, err = b.Build().GetLambdaHandler()(nil, getAPIGWProxyRequest(http.MethodGet, "/nil", nil))
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusNoContent)
+		})
+
+		Convey("Should return error when building workflow with nil typed handler.", func() {
+			b := NewAPIGWProxyWorkflowBuilder()
+			Handle[JSONReq, JSONReq](b, http.MethodPatch, "/", nil)
+
+			_, err := b.BuildE()
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "handler must not be nil")
+		})
+	})
+}
@@ -0,0 +1,91 @@
+package workflow
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	duplicateSlashesRegExp = regexp.MustCompile(`/{2,}`)
+)
+
+// PathNormalization describes how the request path is normalized before
+// it is matched to the workflow routes. The zero value does not change
+// the request path.
+type PathNormalization struct {
+	// StripStage removes the API Gateway stage from the beginning of the
+	// path, e.g. /prod/users becomes /users for requests to the prod stage.
+	StripStage bool
+	// BasePaths are the custom domain base path mappings. The first base
+	// path which the path starts with is removed from the path.
+	BasePaths []string
+	// IgnoreTrailingSlash removes the trailing slash from the request path
+	// and from the route paths, so /users and /users/ are the same path.
+	IgnoreTrailingSlash bool
+	// CollapseSlashes replaces the consecutive slashes with single slash.
+	CollapseSlashes bool
+	// DecodeSegments percent-decodes the path segments after the path is
+	// split, so %2F does not split the segment.
+	DecodeSegments bool
+}
+
+// normalize returns the normalized path and its segments.
+func (n PathNormalization) normalize(path, stage string) (string, []string) {
+	if n.CollapseSlashes {
+		path = duplicateSlashesRegExp.ReplaceAllString(path, "/")
+	}
+
+	if n.StripStage && len(stage) > 0 {
+		path = stripPathPrefix(path, "/"+stage)
+	}
+
+	for _, basePath := range n.BasePaths {
+		if p := stripPathPrefix(path, basePath); p != path {
+			path = p
+			break
+		}
+	}
+
+	path = n.normalizeTemplate(path)
+	segments := splitPath(path)
+	if n.DecodeSegments {
+		for i, s := range segments {
+			if decoded, err := url.PathUnescape(s); err == nil {
+				segments[i] = decoded
+			}
+		}
+	}
+
+	return path, segments
+}
+
+// normalizeTemplate applies the normalization which must be applied to
+// both the route paths and the request paths.
+func (n PathNormalization) normalizeTemplate(path string) string {
+	if n.IgnoreTrailingSlash && len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+
+	return path
+}
+
+// stripPathPrefix removes the prefix from the path if the path starts
+// with the whole prefix segments.
+func stripPathPrefix(path, prefix string) string {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if len(prefix) == 0 || !strings.HasPrefix(path, prefix) {
+		return path
+	}
+
+	rest := path[len(prefix):]
+	if len(rest) == 0 {
+		return "/"
+	}
+
+	if rest[0] != '/' {
+		return path
+	}
+
+	return rest
+}
@@ -0,0 +1,133 @@
+package workflow
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+const (
+	headerCondition = "header"
+	hostCondition   = "host"
+	queryCondition  = "query"
+)
+
+// routeCondition is additional request condition which must be matched
+// by the request to be handled by the route. The condition matches either
+// exact value or regular expression pattern.
+type routeCondition struct {
+	source  string
+	name    string
+	value   string
+	pattern *regexp.Regexp
+}
+
+func (c *routeCondition) String() string {
+	name := c.source
+	if len(c.name) > 0 {
+		name += " " + c.name
+	}
+
+	if c.pattern != nil {
+		return name + "~" + c.pattern.String()
+	}
+
+	return name + "=" + c.value
+}
+
+func (c *routeCondition) matches(evt events.APIGatewayProxyRequest) bool {
+	var value string
+	var ok bool
+	switch c.source {
+	case headerCondition:
+		value, ok = getHeader(evt.Headers, c.name)
+	case hostCondition:
+		value, ok = getHeader(evt.Headers, "Host")
+	case queryCondition:
+		value, ok = evt.QueryStringParameters[c.name]
+	}
+
+	if !ok {
+		return false
+	}
+
+	if c.pattern != nil {
+		return c.pattern.MatchString(value)
+	}
+
+	if c.source == hostCondition {
+		return strings.EqualFold(value, c.value)
+	}
+
+	return value == c.value
+}
+
+func newRouteCondition(source, name, value string) *routeCondition {
+	return &routeCondition{source: source, name: name, value: value}
+}
+
+func newRouteRegExpCondition(source, name, pattern string) (*routeCondition, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s condition pattern %s: %s", source, pattern, err)
+	}
+
+	return &routeCondition{source: source, name: name, pattern: re}, nil
+}
+
+// addCondition adds condition to the route. The conditions which can not
+// be created are reported as route errors when the workflow is built.
+func (rt *route) addCondition(c *routeCondition, err error) {
+	if err != nil {
+		if rt.err == nil {
+			rt.err = fmt.Errorf("invalid route %s: %s", rt, err)
+		}
+
+		return
+	}
+
+	rt.conditions = append(rt.conditions, c)
+}
+
+// matchesConditions reports whether the request matches all route conditions.
+func (rt *route) matchesConditions(evt events.APIGatewayProxyRequest) bool {
+	for _, c := range rt.conditions {
+		if !c.matches(evt) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// getHeader returns the value of the header with case-insensitive name.
+func getHeader(headers map[string]string, name string) (string, bool) {
+	if v, ok := headers[name]; ok {
+		return v, true
+	}
+
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+
+	return "", false
+}
+
+// getMultiValueHeader returns the values of the header with case-insensitive name.
+func getMultiValueHeader(headers map[string][]string, name string) ([]string, bool) {
+	if v, ok := headers[name]; ok {
+		return v, true
+	}
+
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+
+	return nil, false
+}
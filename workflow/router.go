@@ -0,0 +1,488 @@
+package workflow
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	pathParameterRegExp = regexp.MustCompile(`^\{(.+)\}$`)
+)
+
+// router matches request paths to the registered routes. The routes are
+// stored in a segment tree and the lookup follows the API Gateway
+// precedence rules: static segments are preferred over path parameters
+// and path parameters are preferred over greedy path parameters. Path
+// parameters with constraints are preferred over path parameters without
+// constraints.
+type router struct {
+	root   *routeNode
+	routes []*route
+	// The nodes of the routes by API Gateway resource path. Used to match
+	// the requests by the resource already matched by API Gateway.
+	resources map[string]*routeNode
+}
+
+type routeNode struct {
+	static map[string]*routeNode
+	params []*routeNode
+	greedy *routeNode
+	// The routes by method. The routes for each method are sorted by
+	// the number of their conditions, so the most specific route which
+	// matches the request is found first.
+	routes map[string][]*route
+
+	// The name and the constraint of the parameter matched by the node and
+	// the path of the route which has added the node. Used to match the
+	// path segments and to report ambiguous routes.
+	paramName       string
+	paramConstraint *pathConstraint
+	paramPath       string
+}
+
+type route struct {
+	method           string
+	path             string
+	paramNames       []string
+	paramConstraints []*pathConstraint
+	conditions       []*routeCondition
+	hData            *handlerData
+	group            *APIGWProxyRouteGroup
+	err              error
+}
+
+// routeLookup is the state of single route lookup.
+type routeLookup struct {
+	method string
+	// accept reports whether the route matches the request conditions.
+	accept func(rt *route) bool
+	// The first node with routes which matches the path no matter the method.
+	matched *routeNode
+}
+
+// add registers the route in the tree. Returns error if the route is
+// invalid, already registered or ambiguous with the registered routes.
+func (r *router) add(rt *route) error {
+	n := r.root
+	segments := splitPath(rt.path)
+	for i, segment := range segments {
+		name, constraint, isParam, isGreedy := parsePathSegment(segment)
+		switch {
+		case isGreedy:
+			if i != len(segments)-1 {
+				return fmt.Errorf("invalid route %s %s: greedy path parameter {%s+} must be the last segment", rt.method, rt.path, name)
+			}
+
+			if n.greedy == nil {
+				n.greedy = newParamRouteNode(name, nil, rt.path)
+			}
+			n = n.greedy
+		case isParam:
+			c, err := newPathConstraint(constraint)
+			if err != nil {
+				return fmt.Errorf("invalid route %s %s: invalid constraint of path parameter {%s}: %s", rt.method, rt.path, name, err)
+			}
+
+			if p := n.getOverlappingParamNode(c); p != nil {
+				return fmt.Errorf("ambiguous route %s %s: path parameter {%s:%s} overlaps {%s:%s} in %s", rt.method, rt.path, name, c, p.paramName, p.paramConstraint, p.paramPath)
+			}
+
+			n = n.getParamNode(name, c, rt.path)
+		default:
+			child, ok := n.static[segment]
+			if !ok {
+				child = newRouteNode()
+				n.static[segment] = child
+			}
+			n = child
+		}
+
+		if isParam || isGreedy {
+			if n.paramName != name {
+				return fmt.Errorf("ambiguous route %s %s: path parameter {%s} conflicts with {%s} in %s", rt.method, rt.path, name, n.paramName, n.paramPath)
+			}
+
+			rt.paramNames = append(rt.paramNames, name)
+			rt.paramConstraints = append(rt.paramConstraints, n.paramConstraint)
+		}
+	}
+
+	method := strings.ToUpper(rt.method)
+	for _, existing := range n.routes[method] {
+		if existing.getConditionsKey() == rt.getConditionsKey() {
+			return fmt.Errorf("duplicate route %s", rt)
+		}
+	}
+
+	n.addRoute(method, rt)
+	r.routes = append(r.routes, rt)
+
+	// The routes which differ only by the path parameter constraints have
+	// the same resource path, so all of them are kept in the resource node.
+	resource := rt.getResource()
+	if _, ok := r.resources[resource]; !ok {
+		r.resources[resource] = newRouteNode()
+	}
+
+	r.resources[resource].addResourceRoute(method, rt)
+	return nil
+}
+
+// lookup returns the route which matches the provided method and path and
+// the values of the path parameters in the order in which they appear in
+// the route path. If there is no such route but the path matches routes
+// registered for other methods, these methods are returned as allowed.
+func (r *router) lookup(method, path string) (rt *route, values []string, allowedMethods []string) {
+	return r.lookupSegments(method, splitPath(path), nil)
+}
+
+// lookupSegments is like lookup, but matches already split path and only
+// the routes accepted by the accept func if it is not nil.
+func (r *router) lookupSegments(method string, segments []string, accept func(*route) bool) (rt *route, values []string, allowedMethods []string) {
+	l := &routeLookup{method: strings.ToUpper(method), accept: accept}
+	rt, values = r.root.match(l, segments, []string{})
+	if rt == nil {
+		allowedMethods = l.getAllowedMethods()
+	}
+
+	return rt, values, allowedMethods
+}
+
+// lookupResource returns the route which matches the provided method and API
+// Gateway resource path. If there is no such route but there are routes
+// for other methods for the resource, these methods are returned as allowed.
+func (r *router) lookupResource(method, resource string, accept func(*route) bool) (rt *route, allowedMethods []string) {
+	n, ok := r.resources[resource]
+	if !ok {
+		return nil, nil
+	}
+
+	l := &routeLookup{method: strings.ToUpper(method), accept: accept}
+	rt, _ = n.matchMethod(l, nil)
+	if rt == nil {
+		return nil, l.getAllowedMethods()
+	}
+
+	return rt, nil
+}
+
+// getAllowedMethods returns the methods of the node matched by the path. If
+// the node has routes for the lookup method, their conditions did not match
+// the request, so there are no allowed methods.
+func (l *routeLookup) getAllowedMethods() []string {
+	if l.matched == nil {
+		return nil
+	}
+
+	routes := l.matched.routes
+	if len(routes[l.method]) > 0 || (l.method == http.MethodHead && len(routes[http.MethodGet]) > 0) {
+		return nil
+	}
+
+	return l.matched.methods()
+}
+
+// getParamNode returns the child node for path parameter with the provided
+// constraint and creates it if it does not exist. The nodes of parameters
+// with constraints are kept before the node of parameter without constraint.
+func (n *routeNode) getParamNode(name string, constraint *pathConstraint, path string) *routeNode {
+	for _, p := range n.params {
+		if p.paramConstraint.String() == constraint.String() {
+			return p
+		}
+	}
+
+	child := newParamRouteNode(name, constraint, path)
+	if constraint == nil {
+		n.params = append(n.params, child)
+		return child
+	}
+
+	i := 0
+	for i < len(n.params) && n.params[i].paramConstraint != nil {
+		i++
+	}
+
+	n.params = append(n.params, nil)
+	copy(n.params[i+1:], n.params[i:])
+	n.params[i] = child
+	return child
+}
+
+// getOverlappingParamNode returns the child node of path parameter with
+// other constraint which matches the same segments as the provided one.
+// Such nodes are ambiguous, because the segment is matched by the first
+// node. Returns nil if there is no such node.
+func (n *routeNode) getOverlappingParamNode(constraint *pathConstraint) *routeNode {
+	if constraint == nil {
+		return nil
+	}
+
+	for _, p := range n.params {
+		if p.paramConstraint != nil && p.paramConstraint.String() != constraint.String() && p.paramConstraint.overlaps(constraint) {
+			return p
+		}
+	}
+
+	return nil
+}
+
+// match finds the route for the lookup in the subtree.
+func (n *routeNode) match(l *routeLookup, segments, values []string) (*route, []string) {
+	if len(segments) == 0 {
+		return n.matchMethod(l, values)
+	}
+
+	segment := segments[0]
+	if child, ok := n.static[segment]; ok {
+		if rt, params := child.match(l, segments[1:], values); rt != nil {
+			return rt, params
+		}
+	}
+
+	if len(segment) > 0 {
+		for _, p := range n.params {
+			if !p.paramConstraint.matches(segment) {
+				continue
+			}
+
+			if rt, params := p.match(l, segments[1:], append(values, segment)); rt != nil {
+				return rt, params
+			}
+		}
+	}
+
+	if n.greedy != nil {
+		rest := strings.Join(segments, "/")
+		if len(rest) > 0 {
+			return n.greedy.matchMethod(l, append(values, rest))
+		}
+	}
+
+	return nil, nil
+}
+
+func (n *routeNode) matchMethod(l *routeLookup, values []string) (*route, []string) {
+	if len(n.routes) == 0 {
+		return nil, nil
+	}
+
+	if l.matched == nil {
+		l.matched = n
+	}
+
+	if rt := n.findRoute(l.method, l.accept); rt != nil {
+		return rt, values
+	}
+
+	// HEAD requests are handled by the GET route if there is no
+	// explicitly registered HEAD route.
+	if l.method == http.MethodHead {
+		if rt := n.findRoute(http.MethodGet, l.accept); rt != nil {
+			return rt, values
+		}
+	}
+
+	return nil, nil
+}
+
+// findRoute returns the most specific route for the method accepted by
+// the accept func.
+func (n *routeNode) findRoute(method string, accept func(*route) bool) *route {
+	for _, rt := range n.routes[method] {
+		if accept == nil || accept(rt) {
+			return rt
+		}
+	}
+
+	return nil
+}
+
+// addRoute adds the route after the routes with the same or greater number
+// of conditions, so the routes with the same number of conditions which
+// match the same request are matched in the order in which they are added.
+func (n *routeNode) addRoute(method string, rt *route) {
+	routes := n.routes[method]
+	i := 0
+	for i < len(routes) && len(routes[i].conditions) >= len(rt.conditions) {
+		i++
+	}
+
+	routes = append(routes, nil)
+	copy(routes[i+1:], routes[i:])
+	routes[i] = rt
+	n.routes[method] = routes
+}
+
+// addResourceRoute adds the route to the routes of resource node in the
+// order in which they are matched by path: the routes are compared by
+// their first path parameter which is constrained only in one of them and
+// then by the number of their conditions.
+func (n *routeNode) addResourceRoute(method string, rt *route) {
+	routes := n.routes[method]
+	i := 0
+	for i < len(routes) && !rt.precedesResourceRoute(routes[i]) {
+		i++
+	}
+
+	routes = append(routes, nil)
+	copy(routes[i+1:], routes[i:])
+	routes[i] = rt
+	n.routes[method] = routes
+}
+
+// methods returns the sorted HTTP methods which can be handled by the node
+// including the automatically handled HEAD and OPTIONS methods.
+func (n *routeNode) methods() []string {
+	methods := map[string]bool{http.MethodOptions: true}
+	for m := range n.routes {
+		methods[m] = true
+	}
+
+	if methods[http.MethodGet] {
+		methods[http.MethodHead] = true
+	}
+
+	res := make([]string, 0, len(methods))
+	for m := range methods {
+		res = append(res, m)
+	}
+
+	sort.Strings(res)
+	return res
+}
+
+func (rt *route) String() string {
+	res := strings.ToUpper(rt.method) + " " + rt.path
+	if len(rt.conditions) > 0 {
+		res += " [" + rt.getConditionsKey() + "]"
+	}
+
+	return res
+}
+
+// getConditionsKey returns the conditions of the route as string which
+// is the same for the same conditions no matter their order.
+func (rt *route) getConditionsKey() string {
+	conditions := make([]string, 0, len(rt.conditions))
+	for _, c := range rt.conditions {
+		conditions = append(conditions, c.String())
+	}
+
+	sort.Strings(conditions)
+	return strings.Join(conditions, ", ")
+}
+
+// getResource returns the API Gateway resource path of the route. The
+// resource path is the route path without the path parameter constraints.
+// precedesResourceRoute reports whether the route is matched before the
+// other route with the same resource.
+func (rt *route) precedesResourceRoute(other *route) bool {
+	for i := range rt.paramConstraints {
+		constrained, otherConstrained := rt.paramConstraints[i] != nil, other.paramConstraints[i] != nil
+		if constrained != otherConstrained {
+			return constrained
+		}
+	}
+
+	return len(rt.conditions) > len(other.conditions)
+}
+
+// matchesParams reports whether the path parameters match the route
+// path parameter constraints.
+func (rt *route) matchesParams(params map[string]string) bool {
+	for i, name := range rt.paramNames {
+		if !rt.paramConstraints[i].matches(params[name]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (rt *route) getResource() string {
+	segments := splitPath(rt.path)
+	for i, segment := range segments {
+		name, _, isParam, isGreedy := parsePathSegment(segment)
+		if isParam {
+			segments[i] = "{" + name + "}"
+		} else if isGreedy {
+			segments[i] = "{" + name + "+}"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+func newRouter() *router {
+	return &router{root: newRouteNode(), routes: []*route{}, resources: make(map[string]*routeNode)}
+}
+
+func newRouteNode() *routeNode {
+	return &routeNode{static: make(map[string]*routeNode), routes: make(map[string][]*route)}
+}
+
+func newParamRouteNode(name string, constraint *pathConstraint, path string) *routeNode {
+	n := newRouteNode()
+	n.paramName = name
+	n.paramConstraint = constraint
+	n.paramPath = path
+	return n
+}
+
+// joinPath joins the path prefix and the path. The path / is joined as
+// the prefix itself, so /v1 and / are joined as /v1.
+func joinPath(prefix, path string) string {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if len(path) == 0 || path == "/" {
+		if len(prefix) == 0 {
+			return "/"
+		}
+
+		return prefix
+	}
+
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	return prefix + path
+}
+
+// validatePathPrefix checks if the prefix of route group or mounted
+// workflow is empty or starts with slash.
+func validatePathPrefix(prefix string) error {
+	if len(prefix) > 0 && !strings.HasPrefix(prefix, "/") {
+		return fmt.Errorf("path prefix must start with /, got %s", prefix)
+	}
+
+	return nil
+}
+
+// splitPath splits the path to segments. The empty segments are preserved
+// so /test and /test/ are different paths.
+func splitPath(path string) []string {
+	return strings.Split(path, "/")
+}
+
+// parsePathSegment parses path segments like {id}, {id:int} and {proxy+}.
+func parsePathSegment(segment string) (name, constraint string, isParam, isGreedy bool) {
+	m := pathParameterRegExp.FindStringSubmatch(segment)
+	if m == nil {
+		return "", "", false, false
+	}
+
+	name = m[1]
+	if i := strings.Index(name, ":"); i >= 0 {
+		return name[:i], name[i+1:], true, false
+	}
+
+	if strings.HasSuffix(name, "+") {
+		return strings.TrimSuffix(name, "+"), "", false, true
+	}
+
+	return name, "", true, false
+}
@@ -0,0 +1,186 @@
+package workflow
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"strconv"
+)
+
+// maxConstraintSamples limits the number of the sample segments generated
+// from regular expression constraint.
+const maxConstraintSamples = 64
+
+// pathConstraintMatchers are the predefined path parameter constraints.
+// Each matcher returns whether the segment fits the constraint.
+var pathConstraintMatchers = map[string]func(string) bool{
+	"int": func(s string) bool {
+		_, err := strconv.ParseInt(s, 10, 64)
+		return err == nil
+	},
+	"float": func(s string) bool {
+		_, err := strconv.ParseFloat(s, 64)
+		return err == nil
+	},
+	"bool": func(s string) bool {
+		_, err := strconv.ParseBool(s)
+		return err == nil
+	},
+	"uuid": regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`).MatchString,
+}
+
+// pathConstraintSamples are segments which fit the predefined constraints.
+// They are used to find the constraints which match the same segments.
+var pathConstraintSamples = map[string][]string{
+	"int":   {"0", "1", "-1", "42"},
+	"float": {"0", "1.5", "-1.5", "1e3"},
+	"bool":  {"true", "false", "1", "0", "t", "F"},
+	"uuid":  {"123e4567-e89b-12d3-a456-426614174000"},
+}
+
+// pathConstraint restricts the segments which can be matched by path
+// parameter. The constraint is either one of the predefined constraints
+// (int, float, bool and uuid) or regular expression which must match
+// the whole segment. The matched segments are not converted, the path
+// parameters are bound to the handler inputs by their field types.
+type pathConstraint struct {
+	expr  string
+	match func(string) bool
+	// samples are segments which fit the constraint.
+	samples []string
+}
+
+// newPathConstraint creates constraint from its expression. Returns nil
+// for empty expression.
+func newPathConstraint(expr string) (*pathConstraint, error) {
+	if len(expr) == 0 {
+		return nil, nil
+	}
+
+	if match, ok := pathConstraintMatchers[expr]; ok {
+		return &pathConstraint{expr: expr, match: match, samples: pathConstraintSamples[expr]}, nil
+	}
+
+	re, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pathConstraint{expr: expr, match: re.MatchString, samples: getRegExpSamples(parsed.Simplify())}, nil
+}
+
+func (c *pathConstraint) String() string {
+	if c == nil {
+		return ""
+	}
+
+	return c.expr
+}
+
+func (c *pathConstraint) matches(segment string) bool {
+	if c == nil {
+		return true
+	}
+
+	return c.match(segment)
+}
+
+// overlaps reports whether both constraints match some segment. The
+// check uses the sample segments of the constraints, so it finds the
+// common overlaps like [0-9]+ and int, but not all of them.
+func (c *pathConstraint) overlaps(other *pathConstraint) bool {
+	for _, s := range c.samples {
+		if len(s) > 0 && other.matches(s) {
+			return true
+		}
+	}
+
+	for _, s := range other.samples {
+		if len(s) > 0 && c.matches(s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getRegExpSamples returns strings matched by the regular expression. The
+// char classes are represented by the bounds of their ranges and the
+// repetitions by up to two repeats.
+func getRegExpSamples(re *syntax.Regexp) []string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return []string{string(re.Rune)}
+	case syntax.OpCharClass:
+		samples := []string{}
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			samples = append(samples, string(re.Rune[i]), string(re.Rune[i+1]))
+		}
+
+		return limitSamples(samples)
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return []string{"0", "a", "-"}
+	case syntax.OpCapture:
+		return getRegExpSamples(re.Sub[0])
+	case syntax.OpStar:
+		sub := getRegExpSamples(re.Sub[0])
+		return limitSamples(append(append([]string{""}, sub...), concatSamples(sub, sub)...))
+	case syntax.OpPlus:
+		sub := getRegExpSamples(re.Sub[0])
+		return limitSamples(append(sub, concatSamples(sub, sub)...))
+	case syntax.OpQuest:
+		return limitSamples(append([]string{""}, getRegExpSamples(re.Sub[0])...))
+	case syntax.OpRepeat:
+		sub := getRegExpSamples(re.Sub[0])
+		samples := []string{""}
+		for i := 0; i < re.Min; i++ {
+			samples = concatSamples(samples, sub)
+		}
+
+		if re.Min == 0 && re.Max != 0 {
+			samples = limitSamples(append(samples, sub...))
+		}
+
+		return samples
+	case syntax.OpConcat:
+		samples := []string{""}
+		for _, sub := range re.Sub {
+			samples = concatSamples(samples, getRegExpSamples(sub))
+		}
+
+		return samples
+	case syntax.OpAlternate:
+		samples := []string{}
+		for _, sub := range re.Sub {
+			samples = append(samples, getRegExpSamples(sub)...)
+		}
+
+		return limitSamples(samples)
+	}
+
+	// The empty matches and the anchors.
+	return []string{""}
+}
+
+func concatSamples(prefixes, suffixes []string) []string {
+	samples := []string{}
+	for _, p := range prefixes {
+		for _, s := range suffixes {
+			samples = append(samples, p+s)
+		}
+	}
+
+	return limitSamples(samples)
+}
+
+func limitSamples(samples []string) []string {
+	if len(samples) > maxConstraintSamples {
+		return samples[:maxConstraintSamples]
+	}
+
+	return samples
+}
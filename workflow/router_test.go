@@ -0,0 +1,193 @@
+package workflow
+
+import (
+	"net/http"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRouter(t *testing.T) {
+	Convey("Router", t, func() {
+		r := newRouter()
+		addRoute := func(method, path string) *route {
+			rt := &route{method: method, path: path, hData: &handlerData{}}
+			So(r.add(rt), ShouldBeNil)
+			return rt
+		}
+
+		Convey("Should prefer static segments over parameters and parameters over greedy parameters.", func() {
+			static := addRoute(http.MethodGet, "/users/me")
+			param := addRoute(http.MethodGet, "/users/{id}")
+			greedy := addRoute(http.MethodGet, "/users/{proxy+}")
+
+			rt, values, _ := r.lookup(http.MethodGet, "/users/me")
+			So(rt, ShouldEqual, static)
+			So(values, ShouldBeEmpty)
+
+			rt, values, _ = r.lookup(http.MethodGet, "/users/5")
+			So(rt, ShouldEqual, param)
+			So(values, ShouldResemble, []string{"5"})
+
+			rt, values, _ = r.lookup(http.MethodGet, "/users/5/orders")
+			So(rt, ShouldEqual, greedy)
+			So(values, ShouldResemble, []string{"5/orders"})
+		})
+
+		Convey("Should not depend on the registration order.", func() {
+			param := addRoute(http.MethodGet, "/users/{id}")
+			static := addRoute(http.MethodGet, "/users/me")
+
+			rt, _, _ := r.lookup(http.MethodGet, "/users/me")
+			So(rt, ShouldEqual, static)
+
+			rt, _, _ = r.lookup(http.MethodGet, "/users/you")
+			So(rt, ShouldEqual, param)
+		})
+
+		Convey("Should match whole segments only.", func() {
+			addRoute(http.MethodGet, "/users/{id}")
+
+			rt, _, _ := r.lookup(http.MethodGet, "/users/1/orders")
+			So(rt, ShouldBeNil)
+
+			rt, _, _ = r.lookup(http.MethodGet, "/users/")
+			So(rt, ShouldBeNil)
+		})
+
+		Convey("Should backtrack to parameters when the static branch does not match.", func() {
+			addRoute(http.MethodGet, "/users/me/profile")
+			param := addRoute(http.MethodGet, "/users/{id}/orders")
+
+			rt, values, _ := r.lookup(http.MethodGet, "/users/me/orders")
+			So(rt, ShouldEqual, param)
+			So(values, ShouldResemble, []string{"me"})
+		})
+
+		Convey("Should match the HTTP method.", func() {
+			get := addRoute(http.MethodGet, "/users")
+			post := addRoute(http.MethodPost, "/users")
+
+			rt, _, _ := r.lookup(http.MethodPost, "/users")
+			So(rt, ShouldEqual, post)
+
+			rt, _, _ = r.lookup("get", "/users")
+			So(rt, ShouldEqual, get)
+
+			rt, _, _ = r.lookup(http.MethodDelete, "/users")
+			So(rt, ShouldBeNil)
+		})
+
+		Convey("Should handle HEAD requests with the GET route.", func() {
+			get := addRoute(http.MethodGet, "/users")
+
+			rt, _, _ := r.lookup(http.MethodHead, "/users")
+			So(rt, ShouldEqual, get)
+
+			head := addRoute(http.MethodHead, "/users")
+
+			rt, _, _ = r.lookup(http.MethodHead, "/users")
+			So(rt, ShouldEqual, head)
+		})
+
+		Convey("Should return the allowed methods when the path matches but the method does not.", func() {
+			addRoute(http.MethodPost, "/users/{id}")
+			addRoute(http.MethodGet, "/users/{id}")
+			addRoute(http.MethodPut, "/users/me")
+
+			rt, _, allowed := r.lookup(http.MethodDelete, "/users/5")
+			So(rt, ShouldBeNil)
+			So(allowed, ShouldResemble, []string{http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPost})
+
+			rt, _, allowed = r.lookup(http.MethodDelete, "/users/me")
+			So(rt, ShouldBeNil)
+			So(allowed, ShouldResemble, []string{http.MethodOptions, http.MethodPut})
+
+			rt, _, allowed = r.lookup(http.MethodGet, "/orders")
+			So(rt, ShouldBeNil)
+			So(allowed, ShouldBeEmpty)
+		})
+
+		Convey("Should match path parameters with constraints.", func() {
+			id := addRoute(http.MethodGet, "/orders/{id:int}")
+			sku := addRoute(http.MethodGet, "/orders/{sku:uuid}")
+			name := addRoute(http.MethodGet, "/orders/{name:[a-z-]+}")
+			plain := addRoute(http.MethodGet, "/orders/{value}")
+
+			rt, values, _ := r.lookup(http.MethodGet, "/orders/42")
+			So(rt, ShouldEqual, id)
+			So(values, ShouldResemble, []string{"42"})
+
+			rt, values, _ = r.lookup(http.MethodGet, "/orders/123e4567-e89b-12d3-a456-426614174000")
+			So(rt, ShouldEqual, sku)
+			So(values, ShouldResemble, []string{"123e4567-e89b-12d3-a456-426614174000"})
+
+			rt, _, _ = r.lookup(http.MethodGet, "/orders/my-order")
+			So(rt, ShouldEqual, name)
+
+			rt, _, _ = r.lookup(http.MethodGet, "/orders/My_Order")
+			So(rt, ShouldEqual, plain)
+		})
+
+		Convey("Should not match path parameters which do not fit the constraint.", func() {
+			addRoute(http.MethodGet, "/orders/{id:int}")
+
+			rt, _, allowed := r.lookup(http.MethodGet, "/orders/abc")
+			So(rt, ShouldBeNil)
+			So(allowed, ShouldBeEmpty)
+		})
+
+		Convey("Should return error for invalid constraints.", func() {
+			err := r.add(&route{method: http.MethodGet, path: "/orders/{id:[0-9}"})
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldStartWith, "invalid route GET /orders/{id:[0-9}: invalid constraint of path parameter {id}")
+		})
+
+		Convey("Should return error for duplicate routes.", func() {
+			addRoute(http.MethodGet, "/users/{id}")
+
+			err := r.add(&route{method: "get", path: "/users/{id}"})
+			So(err, ShouldBeError, "duplicate route GET /users/{id}")
+		})
+
+		Convey("Should return error for path parameters with overlapping constraints.", func() {
+			addRoute(http.MethodGet, "/a/{x:[0-9]+}")
+			addRoute(http.MethodGet, "/a/{name:[a-z]+}")
+			addRoute(http.MethodGet, "/a/{id:uuid}")
+
+			err := r.add(&route{method: http.MethodGet, path: "/a/{y:int}", hData: &handlerData{}})
+			So(err, ShouldBeError, "ambiguous route GET /a/{y:int}: path parameter {y:int} overlaps {x:[0-9]+} in /a/{x:[0-9]+}")
+
+			err = r.add(&route{method: http.MethodGet, path: "/a/{flag:bool}", hData: &handlerData{}})
+			So(err.Error(), ShouldContainSubstring, "overlaps")
+
+			err = r.add(&route{method: http.MethodGet, path: "/a/{code:[A-Z]{2,3}|[a-z]{1,2}}", hData: &handlerData{}})
+			So(err.Error(), ShouldContainSubstring, "overlaps")
+
+			addRoute(http.MethodGet, "/b/{x:int}")
+			err = r.add(&route{method: http.MethodGet, path: "/b/{y:float}", hData: &handlerData{}})
+			So(err.Error(), ShouldContainSubstring, "overlaps")
+
+			addRoute(http.MethodGet, "/b/{code:[A-Z]{2}}")
+			addRoute(http.MethodGet, "/b/{x:int}/items")
+		})
+
+		Convey("Should return error for ambiguous path parameters.", func() {
+			addRoute(http.MethodGet, "/users/{id}")
+
+			err := r.add(&route{method: http.MethodPost, path: "/users/{userId}/orders"})
+			So(err, ShouldBeError, "ambiguous route POST /users/{userId}/orders: path parameter {userId} conflicts with {id} in /users/{id}")
+		})
+
+		Convey("Should return error for greedy path parameters which are not the last segment.", func() {
+			err := r.add(&route{method: http.MethodGet, path: "/files/{proxy+}/meta"})
+			So(err, ShouldBeError, "invalid route GET /files/{proxy+}/meta: greedy path parameter {proxy+} must be the last segment")
+		})
+
+		Convey("Should collect the parameter names of the route.", func() {
+			rt := addRoute(http.MethodGet, "/users/{userId}/orders/{orderId}/{proxy+}")
+
+			So(rt.paramNames, ShouldResemble, []string{"userId", "orderId", "proxy"})
+		})
+	})
+}
@@ -1,14 +1,73 @@
 package workflow
 
 import (
+	"context"
 	"fmt"
 	"reflect"
-	"strings"
 )
 
-func getHandlerKey(method, path string) string {
-	// TODO: sanitize path.
-	return fmt.Sprintf("%s-%s", strings.ToLower(method), path)
+var (
+	contextType       = reflect.TypeOf((*Context)(nil)).Elem()
+	lambdaContextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType         = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// validateHandler checks if the handler is func with Context or
+// context.Context as first parameter, optional second parameter in which
+// the request can be decoded and error or result and error as results.
+func validateHandler(handler interface{}) error {
+	if handler == nil {
+		return fmt.Errorf("handler must not be nil")
+	}
+
+	hType := reflect.TypeOf(handler)
+	if hType.Kind() != reflect.Func {
+		return fmt.Errorf("handler must be func, got %s", hType)
+	}
+
+	if hType.IsVariadic() || hType.NumIn() < 1 || hType.NumIn() > 2 {
+		return fmt.Errorf("handler must have one or two parameters, got %s", hType)
+	}
+
+	if hType.In(0) != contextType && hType.In(0) != lambdaContextType {
+		return fmt.Errorf("handler first parameter must be %s or %s, got %s", contextType, lambdaContextType, hType.In(0))
+	}
+
+	if hType.NumIn() > 1 && !isDecodableType(hType.In(1)) {
+		return fmt.Errorf("handler second parameter must be type in which the request can be decoded, got %s", hType.In(1))
+	}
+
+	if hType.NumOut() < 1 || hType.NumOut() > 2 || hType.Out(hType.NumOut()-1) != errorType {
+		return fmt.Errorf("handler must return %s or result and %s, got %s", errorType, errorType, hType)
+	}
+
+	return nil
+}
+
+// checkHandlerInput checks the validation rules of the valid handler
+// input type if the handler has input.
+func checkHandlerInput(handler interface{}, v *validator) error {
+	hType := reflect.TypeOf(handler)
+	if hType.NumIn() < 2 {
+		return nil
+	}
+
+	return v.check(hType.In(1))
+}
+
+func isDecodableType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer, reflect.Complex64, reflect.Complex128:
+		return false
+	case reflect.Interface:
+		return t.NumMethod() == 0
+	}
+
+	return true
 }
 
 func hasResponse(ctx *lambdaCtx) bool {
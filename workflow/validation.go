@@ -0,0 +1,487 @@
+package workflow
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	validateTag  = "validate"
+	patternTag   = "pattern"
+	requiredRule = "required"
+	regexRule    = "regex"
+)
+
+var validatableType = reflect.TypeOf((*Validatable)(nil)).Elem()
+
+// ValidationRule validates the value of field with validate tag like
+// `validate:"rule=param"`. The value is never nil pointer, the pointers are
+// dereferenced and the nil pointers are validated only by the required rule.
+// The returned error message is used as field error message.
+type ValidationRule func(value reflect.Value, param string) error
+
+// Validatable is implemented by the handler inputs which validate
+// themselves. If the returned error is ValidationError or FieldError,
+// its fields are added to the input field errors.
+type Validatable interface {
+	Validate() error
+}
+
+// ValidationError is the error returned when the handler input is not
+// valid. It contains the errors of all invalid fields.
+type ValidationError struct {
+	Fields []FieldError `json:"fields"`
+}
+
+func (e *ValidationError) Error() string {
+	return "validation failed: " + joinFieldErrors(e.Fields)
+}
+
+// validator validates the handler inputs by their validate tags:
+//
+//	type Input struct {
+//		Name  string   `validate:"required,max=50"`
+//		Kind  string   `validate:"oneof=a b c"`
+//		Code  string   `validate:"len=3,regex=^[A-Z]+$"`
+//		Items []Item   `validate:"min=1"`
+//	}
+//
+// The rules are separated by comma, so the patterns with commas like
+// ^[a-z]{1,3}$ must be set with the pattern tag instead of the regex rule:
+//
+//	Code string `validate:"required" pattern:"^[a-z]{1,3}$"`
+//
+// The nested structs and the structs in slices are validated too.
+type validator struct {
+	rules map[string]ValidationRule
+	// custom are the names of the custom rules.
+	custom   map[string]bool
+	patterns sync.Map
+}
+
+// validate returns ValidationError if the value is invalid or other
+// error if it can not be validated.
+func (v *validator) validate(value reflect.Value) error {
+	errs := []FieldError{}
+	if err := v.validateValue(value, "", &errs); err != nil {
+		return err
+	}
+
+	if len(errs) > 0 {
+		return &ValidationError{Fields: errs}
+	}
+
+	return nil
+}
+
+func (v *validator) validateValue(value reflect.Value, field string, errs *[]FieldError) error {
+	// The values of the interface fields are validated too.
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil
+		}
+
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+		if err := v.validateStruct(value, field, errs); err != nil {
+			return err
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if elem := reflect.Indirect(value.Index(i)); elem.Kind() == reflect.Struct {
+				if err := v.validateValue(elem, fmt.Sprintf("%s[%d]", field, i), errs); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	callValidate(value, field, errs)
+	return nil
+}
+
+func (v *validator) validateStruct(value reflect.Value, prefix string, errs *[]FieldError) error {
+	t := value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if isEmbeddedStruct(f) {
+			if err := v.validateStruct(value.Field(i), prefix, errs); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		// Skip the unexported fields.
+		if len(f.PkgPath) > 0 {
+			continue
+		}
+
+		field := joinFieldPath(prefix, f.Name)
+		if err := v.validateField(value.Field(i), f, field, errs); err != nil {
+			return err
+		}
+
+		if err := v.validateValue(value.Field(i), field, errs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (v *validator) validateField(value reflect.Value, f reflect.StructField, field string, errs *[]FieldError) error {
+	source, name := getFieldSource(f)
+	for _, r := range parseRules(f) {
+		if r.name == requiredRule {
+			if isEmptyValue(value) {
+				*errs = append(*errs, FieldError{Field: field, Source: source, Name: name, Rule: r.name, Message: "is required"})
+				return nil
+			}
+
+			continue
+		}
+
+		rule, ok := v.rules[r.name]
+		if !ok {
+			return fmt.Errorf("unknown validation rule %s of field %s", r.name, field)
+		}
+
+		// Only the required rule validates the nil pointers.
+		indirect := reflect.Indirect(value)
+		if !indirect.IsValid() {
+			continue
+		}
+
+		if err := rule(indirect, r.param); err != nil {
+			*errs = append(*errs, FieldError{Field: field, Source: source, Name: name, Rule: r.ruleName(), Message: err.Error()})
+		}
+	}
+
+	return nil
+}
+
+// check checks the validate and pattern tags of the type and its nested
+// types. Returns error for unknown rules, invalid rule parameters and
+// invalid patterns, so they are reported when the workflow is built.
+func (v *validator) check(t reflect.Type) error {
+	return v.checkType(t, "", make(map[reflect.Type]bool))
+}
+
+func (v *validator) checkType(t reflect.Type, field string, checked map[reflect.Type]bool) error {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct || checked[t] {
+		return nil
+	}
+
+	checked[t] = true
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if len(f.PkgPath) > 0 && !isEmbeddedStruct(f) {
+			continue
+		}
+
+		fieldPath := field
+		if !isEmbeddedStruct(f) {
+			fieldPath = joinFieldPath(field, f.Name)
+		}
+
+		for _, r := range parseRules(f) {
+			if err := v.checkRule(r); err != nil {
+				return fmt.Errorf("%s of field %s", err, fieldPath)
+			}
+		}
+
+		if err := v.checkType(f.Type, fieldPath, checked); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (v *validator) checkRule(r ruleCall) error {
+	if r.name == requiredRule {
+		return nil
+	}
+
+	if _, ok := v.rules[r.name]; !ok {
+		return fmt.Errorf("unknown validation rule %s", r.name)
+	}
+
+	// The custom rules replacing the built-in rules check their
+	// parameters themselves.
+	if _, custom := v.custom[r.name]; custom {
+		return nil
+	}
+
+	switch r.name {
+	case "min", "max", "len":
+		if _, err := strconv.ParseFloat(r.param, 64); err != nil {
+			return fmt.Errorf("invalid parameter %s of validation rule %s", r.param, r.name)
+		}
+	case regexRule:
+		if _, err := v.getPattern(r.param); err != nil {
+			return fmt.Errorf("invalid pattern %s", r.param)
+		}
+	}
+
+	return nil
+}
+
+// ruleCall is validation rule with its parameter set in field tag.
+type ruleCall struct {
+	name  string
+	param string
+	// tag is the tag in which the rule is set.
+	tag string
+}
+
+// ruleName returns the name of the rule in the field errors.
+func (r ruleCall) ruleName() string {
+	if r.tag == patternTag {
+		return patternTag
+	}
+
+	return r.name
+}
+
+// parseRules returns the rules of the field validate tag and the regex
+// rule of the field pattern tag.
+func parseRules(f reflect.StructField) []ruleCall {
+	rules := []ruleCall{}
+	if tag, ok := f.Tag.Lookup(validateTag); ok {
+		for _, r := range strings.Split(tag, ",") {
+			name, param := r, ""
+			if i := strings.Index(r, "="); i >= 0 {
+				name, param = r[:i], r[i+1:]
+			}
+
+			if len(name) > 0 {
+				rules = append(rules, ruleCall{name: name, param: param, tag: validateTag})
+			}
+		}
+	}
+
+	if pattern, ok := f.Tag.Lookup(patternTag); ok {
+		rules = append(rules, ruleCall{name: regexRule, param: pattern, tag: patternTag})
+	}
+
+	return rules
+}
+
+func (v *validator) getPattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := v.patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	v.patterns.Store(pattern, re)
+	return re, nil
+}
+
+func newValidator(rules map[string]ValidationRule) *validator {
+	v := &validator{rules: make(map[string]ValidationRule), custom: make(map[string]bool)}
+	v.rules["min"] = validateMin
+	v.rules["max"] = validateMax
+	v.rules["len"] = validateLen
+	v.rules["oneof"] = validateOneOf
+	v.rules[regexRule] = func(value reflect.Value, param string) error {
+		re, err := v.getPattern(param)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %s", param)
+		}
+
+		if value.Kind() != reflect.String || !re.MatchString(value.String()) {
+			return fmt.Errorf("must match pattern %s", param)
+		}
+
+		return nil
+	}
+
+	for name, rule := range rules {
+		v.rules[name] = rule
+		v.custom[name] = true
+	}
+
+	return v
+}
+
+func validateMin(value reflect.Value, param string) error {
+	n, isLen, err := getSize(value, param)
+	if err != nil {
+		return err
+	}
+
+	min, _ := strconv.ParseFloat(param, 64)
+	if n < min {
+		if isLen {
+			return fmt.Errorf("length must be at least %s", param)
+		}
+
+		return fmt.Errorf("must be at least %s", param)
+	}
+
+	return nil
+}
+
+func validateMax(value reflect.Value, param string) error {
+	n, isLen, err := getSize(value, param)
+	if err != nil {
+		return err
+	}
+
+	max, _ := strconv.ParseFloat(param, 64)
+	if n > max {
+		if isLen {
+			return fmt.Errorf("length must be at most %s", param)
+		}
+
+		return fmt.Errorf("must be at most %s", param)
+	}
+
+	return nil
+}
+
+func validateLen(value reflect.Value, param string) error {
+	n, isLen, err := getSize(value, param)
+	if err != nil || !isLen {
+		return fmt.Errorf("invalid len rule")
+	}
+
+	if l, _ := strconv.ParseFloat(param, 64); n != l {
+		return fmt.Errorf("length must be %s", param)
+	}
+
+	return nil
+}
+
+func validateOneOf(value reflect.Value, param string) error {
+	options := strings.Fields(param)
+	s := fmt.Sprint(value.Interface())
+	for _, o := range options {
+		if s == o {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("must be one of %s", strings.Join(options, ", "))
+}
+
+// getSize returns the number value or the length of the value and whether
+// it is length.
+func getSize(value reflect.Value, param string) (n float64, isLen bool, err error) {
+	if _, err := strconv.ParseFloat(param, 64); err != nil {
+		return 0, false, fmt.Errorf("invalid rule parameter %s", param)
+	}
+
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), false, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), false, nil
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), false, nil
+	case reflect.String:
+		return float64(utf8.RuneCountInString(value.String())), true, nil
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(value.Len()), true, nil
+	}
+
+	return 0, false, fmt.Errorf("unsupported type %s", value.Type())
+}
+
+// callValidate calls the Validate method of the value if it has one. The
+// nil values are not validated.
+func callValidate(value reflect.Value, field string, errs *[]FieldError) {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		if value.IsNil() {
+			return
+		}
+	}
+
+	var validatable Validatable
+	if value.Type().Implements(validatableType) {
+		validatable = value.Interface().(Validatable)
+	} else if value.CanAddr() && value.Addr().Type().Implements(validatableType) {
+		validatable = value.Addr().Interface().(Validatable)
+	}
+
+	if validatable == nil {
+		return
+	}
+
+	switch err := validatable.Validate().(type) {
+	case nil:
+	case *ValidationError:
+		for _, f := range err.Fields {
+			f.Field = joinFieldPath(field, f.Field)
+			*errs = append(*errs, f)
+		}
+	case FieldError:
+		err.Field = joinFieldPath(field, err.Field)
+		*errs = append(*errs, err)
+	default:
+		*errs = append(*errs, FieldError{Field: field, Message: err.Error()})
+	}
+}
+
+// getFieldSource returns the request part and the name of the field value.
+func getFieldSource(f reflect.StructField) (source, name string) {
+	for _, tag := range valueTags {
+		if value, ok := f.Tag.Lookup(tag); ok {
+			name, _ := parseBindingTag(value, f.Name)
+			return tag, name
+		}
+	}
+
+	if _, ok := f.Tag.Lookup(bodyTag); ok {
+		return bodyTag, ""
+	}
+
+	if name := strings.Split(f.Tag.Get("json"), ",")[0]; len(name) > 0 && name != "-" {
+		return bodyTag, name
+	}
+
+	return "", ""
+}
+
+func isEmptyValue(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return value.IsNil()
+	case reflect.Slice, reflect.Map, reflect.String, reflect.Array:
+		return value.Len() == 0
+	}
+
+	return reflect.DeepEqual(value.Interface(), reflect.Zero(value.Type()).Interface())
+}
+
+func joinFieldPath(prefix, field string) string {
+	switch {
+	case len(prefix) == 0:
+		return field
+	case len(field) == 0:
+		return prefix
+	case strings.HasPrefix(field, "["):
+		return prefix + field
+	}
+
+	return prefix + "." + field
+}
@@ -0,0 +1,245 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type validationItem struct {
+	Name string `json:"name" validate:"required"`
+}
+
+type validationReq struct {
+	Name   string           `json:"name" validate:"required,max=5"`
+	Kind   string           `query:"kind" validate:"oneof=a b"`
+	Code   string           `validate:"len=3,regex=^[A-Z]+$"`
+	Count  *int             `validate:"min=1"`
+	Items  []validationItem `validate:"min=1"`
+	Nested *validationItem
+}
+
+type selfValidatingReq struct {
+	From int
+	To   int
+}
+
+func (r selfValidatingReq) Validate() error {
+	if r.From > r.To {
+		return FieldError{Field: "From", Message: "must not be after To"}
+	}
+
+	return nil
+}
+
+func TestValidator(t *testing.T) {
+	Convey("Validator", t, func() {
+		v := newValidator(nil)
+		count := 1
+		req := validationReq{
+			Name:   "name",
+			Kind:   "a",
+			Code:   "ABC",
+			Count:  &count,
+			Items:  []validationItem{{Name: "item"}},
+			Nested: &validationItem{Name: "nested"},
+		}
+
+		Convey("Should not return error for valid value.", func() {
+			So(v.validate(reflect.ValueOf(req)), ShouldBeNil)
+			So(v.validate(reflect.ValueOf(&req)), ShouldBeNil)
+		})
+
+		Convey("Should return the errors of all invalid fields.", func() {
+			count = 0
+			req.Name = "long name"
+			req.Kind = "c"
+			req.Code = "AB1"
+			req.Items = []validationItem{{Name: "item"}, {}}
+			req.Nested.Name = ""
+
+			err := v.validate(reflect.ValueOf(req))
+			So(err, ShouldNotBeNil)
+			So(err.(*ValidationError).Fields, ShouldResemble, []FieldError{
+				{Field: "Name", Source: "body", Name: "name", Rule: "max", Message: "length must be at most 5"},
+				{Field: "Kind", Source: "query", Name: "kind", Rule: "oneof", Message: "must be one of a, b"},
+				{Field: "Code", Rule: "regex", Message: "must match pattern ^[A-Z]+$"},
+				{Field: "Count", Rule: "min", Message: "must be at least 1"},
+				{Field: "Items[1].Name", Source: "body", Name: "name", Rule: "required", Message: "is required"},
+				{Field: "Nested.Name", Source: "body", Name: "name", Rule: "required", Message: "is required"},
+			})
+		})
+
+		Convey("Should validate only the required rule of nil pointers.", func() {
+			req.Count = nil
+			So(v.validate(reflect.ValueOf(req)), ShouldBeNil)
+		})
+
+		Convey("Should call the Validate method.", func() {
+			err := v.validate(reflect.ValueOf(selfValidatingReq{From: 2, To: 1}))
+			So(err, ShouldNotBeNil)
+			So(err.(*ValidationError).Fields, ShouldResemble, []FieldError{{Field: "From", Message: "must not be after To"}})
+		})
+
+		Convey("Should skip the nil interface fields and validate the set ones.", func() {
+			type interfaceReq struct {
+				V Validatable
+			}
+
+			So(v.validate(reflect.ValueOf(interfaceReq{})), ShouldBeNil)
+			So(v.validate(reflect.ValueOf(interfaceReq{V: (*selfValidatingReq)(nil)})), ShouldBeNil)
+			err := v.validate(reflect.ValueOf(interfaceReq{V: selfValidatingReq{From: 2, To: 1}}))
+			So(err, ShouldNotBeNil)
+			So(err.(*ValidationError).Fields, ShouldResemble, []FieldError{{Field: "V.From", Message: "must not be after To"}})
+
+			h := NewAPIGWProxyWorkflowBuilder().
+				AddPostHandler("/", func(c Context, req interfaceReq) error {
+					c.SetResponseStatusCode(http.StatusOK)
+					return nil
+				}).
+				Build().
+				GetLambdaHandler()
+
+			res, err := h(nil, getAPIGWProxyRequest(http.MethodPost, "/", map[string]string{}))
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusOK)
+		})
+
+		Convey("Should use the custom rules.", func() {
+			type customReq struct {
+				Name string `validate:"prefix=x"`
+			}
+			v = newValidator(map[string]ValidationRule{
+				"prefix": func(value reflect.Value, param string) error {
+					if !strings.HasPrefix(value.String(), param) {
+						return fmt.Errorf("must start with %s", param)
+					}
+
+					return nil
+				},
+			})
+
+			So(v.validate(reflect.ValueOf(customReq{Name: "xy"})), ShouldBeNil)
+			err := v.validate(reflect.ValueOf(customReq{Name: "y"}))
+			So(err.(*ValidationError).Fields[0].Message, ShouldEqual, "must start with x")
+		})
+
+		Convey("Should return error for unknown rules.", func() {
+			type unknownRuleReq struct {
+				Name string `validate:"unknown"`
+			}
+
+			err := v.validate(reflect.ValueOf(unknownRuleReq{}))
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "unknown validation rule unknown of field Name")
+		})
+
+		Convey("Should validate the pattern tag with commas.", func() {
+			type patternReq struct {
+				Code string `validate:"required" pattern:"^[a-z]{1,3}$"`
+			}
+
+			So(v.validate(reflect.ValueOf(patternReq{Code: "abc"})), ShouldBeNil)
+			err := v.validate(reflect.ValueOf(patternReq{Code: "abcd"}))
+			So(err, ShouldNotBeNil)
+			So(err.(*ValidationError).Fields, ShouldResemble, []FieldError{
+				{Field: "Code", Rule: "pattern", Message: "must match pattern ^[a-z]{1,3}$"},
+			})
+		})
+
+		Convey("Should check the rules of the type and the nested types.", func() {
+			type invalidNested struct {
+				Items []struct {
+					Code string `pattern:"[a-z"`
+				}
+			}
+			type embedded struct {
+				Count int `validate:"min=x"`
+			}
+			type embeddingReq struct {
+				embedded
+			}
+
+			So(v.check(reflect.TypeOf(&req)), ShouldBeNil)
+			So(v.check(reflect.TypeOf(struct {
+				Name string `validate:"requird"`
+			}{})).Error(), ShouldEqual, "unknown validation rule requird of field Name")
+			So(v.check(reflect.TypeOf(invalidNested{})).Error(), ShouldEqual, "invalid pattern [a-z of field Items.Code")
+			So(v.check(reflect.TypeOf(embeddingReq{})).Error(), ShouldEqual, "invalid parameter x of validation rule min of field Count")
+		})
+
+		Convey("Should return error when building workflow with invalid rules.", func() {
+			_, err := NewAPIGWProxyWorkflowBuilder().
+				AddPostHandler("/", func(c Context, req struct {
+					Name string `validate:"requird"`
+				}) error {
+					return nil
+				}).
+				BuildE()
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "invalid routes: invalid handler for route POST /: unknown validation rule requird of field Name")
+
+			_, err = NewAPIGWAuthorizerWorkflowBuilder().
+				SetHandler(func(c Context, req struct {
+					Name string `validate:"min=1,2"`
+				}) error {
+					return nil
+				}).
+				BuildE()
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "invalid authorizer handler: unknown validation rule 2 of field Name")
+		})
+
+		Convey("Should respond with bad request without invoking the handler in the proxy workflow.", func() {
+			flow := ""
+			action := func(name string) Action {
+				return func(c Context) error {
+					flow += name
+					return nil
+				}
+			}
+			h := NewAPIGWProxyWorkflowBuilder().
+				AddPreActions(action("wpre")).
+				AddPostActions(action("wpost")).
+				AddPostHandler("/", func(c Context, req validationItem) error {
+					flow += "handler"
+					return nil
+				}).
+				Build().
+				GetLambdaHandler()
+
+			res, err := h(nil, getAPIGWProxyRequest(http.MethodPost, "/", validationItem{}))
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusBadRequest)
+			So(flow, ShouldEqual, "wprewpost")
+
+			body := ErrorResponse{}
+			So(json.Unmarshal([]byte(res.Body), &body), ShouldBeNil)
+			So(body.Errors, ShouldResemble, []FieldError{
+				{Field: "Name", Source: "body", Name: "name", Rule: "required", Message: "is required"},
+			})
+
+			flow = ""
+			_, err = h(nil, getAPIGWProxyRequest(http.MethodPost, "/", validationItem{Name: "test"}))
+			So(err, ShouldBeNil)
+			So(flow, ShouldEqual, "wprehandlerwpost")
+		})
+
+		Convey("Should return the validation error as handler error in the base workflow.", func() {
+			hData := &handlerData{
+				handler:     func(c Context, req validationItem) error { return nil },
+				preActions:  []Action{},
+				postActions: []Action{},
+			}
+
+			_, err := NewBaseWorkflowBuilder().Build().InvokeHandler(nil, nil, []byte("{}"), hData)
+			So(err, ShouldNotBeNil)
+			So(err.OriginalError(), ShouldHaveSameTypeAs, &ValidationError{})
+		})
+	})
+}
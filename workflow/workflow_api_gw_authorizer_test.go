@@ -83,6 +83,23 @@ func TestAPIGWAuthorizerWorkflow(t *testing.T) {
 
 			So(err, ShouldBeError, "invalid response")
 		})
+
+		Convey("Should return error when building workflow with invalid handler.", func() {
+			w, err := NewAPIGWAuthorizerWorkflowBuilder().
+				SetHandler(func(evt events.APIGatewayCustomAuthorizerRequest) error {
+					return nil
+				}).
+				BuildE()
+
+			So(w, ShouldBeNil)
+			So(err, ShouldBeError, "invalid authorizer handler: handler first parameter must be workflow.Context or context.Context, got events.APIGatewayCustomAuthorizerRequest")
+		})
+
+		Convey("Should return error when building workflow without handler.", func() {
+			_, err := NewAPIGWAuthorizerWorkflowBuilder().BuildE()
+
+			So(err, ShouldBeError, "the authorizer handler is not set")
+		})
 	})
 }
 
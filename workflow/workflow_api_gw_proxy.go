@@ -2,8 +2,11 @@ package workflow
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"net/http"
 	"reflect"
+	"strings"
 
 	"github.com/aws/aws-lambda-go/events"
 )
@@ -11,114 +14,395 @@ import (
 // APIGatewayProxyWorkflow AWS API Gateway Lambda Proxy request/response workflow.
 type APIGatewayProxyWorkflow struct {
 	*BaseWorkflow
-	httpHandlers              map[string]*handlerData
-	parameterizedHTTPHandlers []*parameterizedHandlerData
+	router            *router
+	notFoundHandler   *handlerData
+	pathNormalization PathNormalization
+	resourceRouting   bool
+	decoders          map[string]Decoder
+	decodingOptions   DecodingOptions
+	maxBodySize       int
 }
 
 // GetLambdaHandler returns AWS API Gateway Proxy Lambda handler.
 func (w *APIGatewayProxyWorkflow) GetLambdaHandler() APIGWProxyHandler {
 	return func(ctx context.Context, evt events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
-		hData := w.getHandler(evt)
+		m := w.getHandler(evt)
+		hData := m.hData
 		if hData == nil {
-			return defaultAPIGWProxyHandler(ctx, evt)
-		}
-
-		var reqBytes []byte
-		var err error
-		hType := reflect.TypeOf(hData.handler)
-		// Get event bytes only if the handler has input parameter.
-		if hType.NumIn() > 1 {
-			// Use directly the event body if the handler input parameter
-			// has type string or []byte.
-			handlerInputType := hType.In(1)
-			if handlerInputType.Kind() == reflect.Struct {
-				reqBytes, err = w.getReqBytes(evt)
-				if err != nil {
-					return nil, err
-				}
-			} else {
-				reqBytes = []byte(evt.Body)
+			hData = w.getFallbackHandler(evt, m.allowedMethods)
+		}
+
+		// The requests which can not be bound to the handler input are
+		// responded by request error handler with the workflow actions.
+		req, input, err := w.getRequest(evt, hData, m)
+		if err != nil {
+			rErr := getRequestError(err)
+			if rErr == nil {
+				return nil, err
 			}
+
+			hData, req, input = newRequestErrorHandler(rErr), nil, nil
 		}
 
-		c, err := w.BaseWorkflow.InvokeHandler(ctx, evt, reqBytes, hData)
+		c, err := w.invoke(ctx, evt, req, hData, withPathParameters(m.pathParams), withPath(m.path, evt.Path), withCookieHeaders(getCookieHeaders(evt)), withHandlerInput(input))
 		if err != nil {
 			return nil, err
 		}
 
-		hContext := c.(*lambdaCtx)
-		// Handle Raw response.
-		if hContext.rawResponse != nil {
-			if r, ok := hContext.rawResponse.(events.APIGatewayProxyResponse); ok {
-				return &r, nil
-			} else if r, ok := hContext.rawResponse.(*events.APIGatewayProxyResponse); ok {
-				return r, nil
-			} else {
-				return nil, newErrorWithMessage("invalid raw response")
-			}
+		res, err := w.getResponse(c)
+		if err != nil {
+			return nil, err
 		}
 
-		// Handle response body.
-		var resBytes []byte
-		var mErr error
-		if hContext.response != nil {
-			resBytes, mErr = json.Marshal(hContext.response)
-			if mErr != nil {
-				return nil, newError(mErr)
-			}
+		// The HEAD requests may be handled by GET handlers, but the
+		// response must not have body.
+		if strings.EqualFold(evt.HTTPMethod, http.MethodHead) {
+			res.Body = ""
 		}
 
-		var resBody string
-		if len(resBytes) > 0 {
-			resBody = string(resBytes)
+		return res, nil
+	}
+}
+
+func (w *APIGatewayProxyWorkflow) getResponse(hContext *lambdaCtx) (*events.APIGatewayProxyResponse, Error) {
+	// Handle Raw response.
+	if hContext.rawResponse != nil {
+		if r, ok := hContext.rawResponse.(events.APIGatewayProxyResponse); ok {
+			return &r, nil
+		} else if r, ok := hContext.rawResponse.(*events.APIGatewayProxyResponse); ok {
+			// Copy the raw response so dropping the body of HEAD
+			// requests does not change the user value.
+			res := *r
+			return &res, nil
+		} else {
+			return nil, newErrorWithMessage("invalid raw response")
 		}
+	}
 
-		proxyRes := events.APIGatewayProxyResponse{
-			StatusCode: hContext.responseStatusCode,
-			Body:       resBody,
+	// Handle response body.
+	var resBytes []byte
+	var mErr error
+	if hContext.response != nil {
+		resBytes, mErr = json.Marshal(hContext.response)
+		if mErr != nil {
+			return nil, newError(mErr)
 		}
-		return &proxyRes, nil
 	}
+
+	var resBody string
+	if len(resBytes) > 0 {
+		resBody = string(resBytes)
+	}
+
+	proxyRes := events.APIGatewayProxyResponse{
+		StatusCode: hContext.responseStatusCode,
+		Headers:    hContext.responseHeaders,
+		Body:       resBody,
+	}
+
+	// The cookies are rendered as multi-value headers, because the
+	// response can have only one header with the same name.
+	for _, cookie := range hContext.responseCookies {
+		if v := cookie.String(); len(v) > 0 {
+			if proxyRes.MultiValueHeaders == nil {
+				proxyRes.MultiValueHeaders = make(map[string][]string)
+			}
+
+			proxyRes.MultiValueHeaders["Set-Cookie"] = append(proxyRes.MultiValueHeaders["Set-Cookie"], v)
+		}
+	}
+
+	return &proxyRes, nil
+}
+
+// getCookieHeaders returns the Cookie header values of the request. The
+// multi-value headers are preferred if they are set.
+func getCookieHeaders(evt events.APIGatewayProxyRequest) []string {
+	if values, ok := getMultiValueHeader(evt.MultiValueHeaders, "Cookie"); ok {
+		return values
+	}
+
+	if value, ok := getHeader(evt.Headers, "Cookie"); ok {
+		return []string{value}
+	}
+
+	return nil
 }
 
-func (w *APIGatewayProxyWorkflow) getReqBytes(evt events.APIGatewayProxyRequest) ([]byte, Error) {
-	input := make(map[string]interface{})
-	if len(evt.Body) > 0 {
-		err := json.Unmarshal([]byte(evt.Body), &input)
+// getRequest returns the handler input created from the request. The
+// structs with binding tags, the inputs of requests with body which is
+// not JSON and the string and []byte inputs of base64 encoded bodies are
+// bound by the request binder. The JSON bodies of the other inputs are
+// decoded as they are and the request parameters are merged in the
+// structs for backward compatibility. The requests of the typed handlers
+// are decoded directly in their inputs, which are returned too.
+func (w *APIGatewayProxyWorkflow) getRequest(evt events.APIGatewayProxyRequest, hData *handlerData, m *routeMatch) (*reflect.Value, interface{}, Error) {
+	maxBodySize := w.maxBodySize
+	if hData.maxBodySize > 0 {
+		maxBodySize = hData.maxBodySize
+	}
+
+	if maxBodySize > 0 && getBodySize(evt) > maxBodySize {
+		return nil, nil, newError(newRequestEntityTooLargeError(maxBodySize))
+	}
+
+	inputType := hData.getInputType()
+	if inputType == nil {
+		return nil, nil, nil
+	}
+
+	// The handlers get the decoded body, but the event in the
+	// context is not changed.
+	if evt.IsBase64Encoded {
+		body, err := base64.StdEncoding.DecodeString(evt.Body)
 		if err != nil {
-			return nil, newError(err)
+			msg := "invalid base64 encoded body: " + err.Error()
+			return nil, nil, newError(&BindingError{Fields: []FieldError{{Source: bodyTag, Message: msg}}})
 		}
+
+		evt.Body = string(body)
+	}
+
+	contentType, _ := getHeader(evt.Headers, "Content-Type")
+	decoders := w.decoders
+	if hData.decoders != nil {
+		decoders = hData.decoders
+	}
+
+	decoder, mediaType, params, err := getDecoder(decoders, contentType)
+	if err != nil && len(evt.Body) > 0 && !hasRawBody(inputType) {
+		return nil, nil, newError(err)
+	}
+
+	isJSON := mediaType == JSONMediaType || strings.HasSuffix(mediaType, "+json")
+	if opts := w.getDecodingOptions(hData); isJSON && opts != nil {
+		decoder = newJSONDecoder(*opts)
+	}
+
+	// The input is decoded in new value of the input type or of the
+	// pointed type for pointer inputs.
+	var input interface{}
+	if hData.typed != nil {
+		input = hData.typed.newInput()
+	} else if inputType.Kind() == reflect.Ptr {
+		input = reflect.New(inputType.Elem()).Interface()
+	} else {
+		input = reflect.New(inputType).Interface()
+	}
+
+	if hasBindingTags(inputType) || !isJSON || (evt.IsBase64Encoded && isRawBodyType(inputType)) {
+		if err := newRequestBinder(evt, m.pathParams, decoder, params).bind(input); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		if len(evt.Body) > 0 {
+			if err := decoder([]byte(evt.Body), params, input); err != nil {
+				return nil, nil, newError(&BindingError{Fields: []FieldError{{Source: bodyTag, Message: err.Error()}}})
+			}
+		}
+
+		if inputType.Kind() == reflect.Struct {
+			if err := mergeRequestParams(evt, m.pathParams, input); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	req := reflect.ValueOf(input)
+	if inputType.Kind() != reflect.Ptr {
+		req = req.Elem()
+	}
+
+	if hData.typed == nil {
+		input = nil
+	}
+
+	return &req, input, nil
+}
+
+// getDecodingOptions returns the JSON decoding options of the route or of
+// the workflow. Returns nil if the default decoding should be used.
+func (w *APIGatewayProxyWorkflow) getDecodingOptions(hData *handlerData) *DecodingOptions {
+	if hData.decodingOptions != nil {
+		return hData.decodingOptions
+	}
+
+	if w.decodingOptions != (DecodingOptions{}) {
+		return &w.decodingOptions
 	}
 
+	return nil
+}
+
+// getBodySize returns the size of the request body. The size of base64
+// encoded body is the size of the decoded body.
+func getBodySize(evt events.APIGatewayProxyRequest) int {
+	if evt.IsBase64Encoded {
+		padding := len(evt.Body) - len(strings.TrimRight(evt.Body, "="))
+		return base64.StdEncoding.DecodedLen(len(evt.Body)) - padding
+	}
+
+	return len(evt.Body)
+}
+
+// mergeRequestParams sets the headers, the path parameters and the query
+// parameters to the input fields with the same JSON names. The parameter
+// values are converted to the field types like the bound values.
+func mergeRequestParams(evt events.APIGatewayProxyRequest, pathParams map[string]string, out interface{}) Error {
+	params := make(map[string]requestParam)
 	for k, v := range evt.Headers {
-		input[k] = v
+		params[k] = requestParam{source: headerTag, value: v}
 	}
 
-	for k, v := range evt.PathParameters {
-		input[k] = v
+	for k, v := range pathParams {
+		params[k] = requestParam{source: pathTag, value: v}
 	}
 
 	for k, v := range evt.QueryStringParameters {
-		input[k] = v
+		params[k] = requestParam{source: queryTag, value: v}
 	}
 
-	res, err := json.Marshal(input)
-	return res, newError(err)
+	errs := []FieldError{}
+	mergeStructParams(reflect.ValueOf(out).Elem(), "", params, &errs)
+	if len(errs) > 0 {
+		return newError(&BindingError{Fields: errs})
+	}
+
+	return nil
+}
+
+type requestParam struct {
+	source string
+	value  string
+}
+
+func mergeStructParams(v reflect.Value, prefix string, params map[string]requestParam, errs *[]FieldError) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if isEmbeddedStruct(f) {
+			mergeStructParams(v.Field(i), prefix, params, errs)
+			continue
+		}
+
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if len(f.PkgPath) > 0 || name == "-" {
+			continue
+		}
+
+		if len(name) == 0 {
+			name = f.Name
+		}
+
+		p, ok := getRequestParam(params, name)
+		if !ok {
+			continue
+		}
+
+		if f.Type.Kind() == reflect.Interface && f.Type.NumMethod() == 0 {
+			v.Field(i).Set(reflect.ValueOf(p.value))
+		} else {
+			setFieldValues(v.Field(i), joinFieldPath(prefix, f.Name), p.source, name, []string{p.value}, false, errs)
+		}
+	}
 }
 
-func (w *APIGatewayProxyWorkflow) getHandler(evt events.APIGatewayProxyRequest) *handlerData {
-	path := evt.Path
-	key := getHandlerKey(evt.HTTPMethod, path)
-	res, ok := w.httpHandlers[key]
-	if ok {
-		return res
+// getRequestParam returns the parameter with the field name. The names
+// are matched case-insensitively like the JSON object keys.
+func getRequestParam(params map[string]requestParam, name string) (requestParam, bool) {
+	if p, ok := params[name]; ok {
+		return p, true
 	}
 
-	for _, h := range w.parameterizedHTTPHandlers {
-		if h.method == evt.HTTPMethod && h.pathRegExp.MatchString(path) {
-			return h.hData
+	for k, p := range params {
+		if strings.EqualFold(k, name) {
+			return p, true
 		}
 	}
 
-	return nil
+	return requestParam{}, false
+}
+
+// getHandler returns the handler registered for the request and the
+// path parameters of the request. The parameters extracted from the
+// request path override the ones set by API Gateway. If there is no
+// handler for the request method, the methods registered for the
+// request path are returned.
+func (w *APIGatewayProxyWorkflow) getHandler(evt events.APIGatewayProxyRequest) *routeMatch {
+	m := &routeMatch{pathParams: make(map[string]string, len(evt.PathParameters))}
+	for k, v := range evt.PathParameters {
+		m.pathParams[k] = v
+	}
+
+	var segments []string
+	m.path, segments = w.pathNormalization.normalize(evt.Path, evt.RequestContext.Stage)
+	if w.isResourceRoutingEnabled(evt) {
+		return w.getResourceHandler(evt, m)
+	}
+
+	rt, values, allowedMethods := w.router.lookupSegments(evt.HTTPMethod, segments, getConditionsMatcher(evt))
+	if rt == nil {
+		m.allowedMethods = allowedMethods
+		return m
+	}
+
+	m.hData = rt.hData
+	for i, name := range rt.paramNames {
+		m.pathParams[name] = values[i]
+	}
+
+	return m
+}
+
+// getResourceHandler returns the handler registered for the request API Gateway
+// resource. The path parameters are the ones set by API Gateway. The resource
+// does not have the route constraints, so the first route whose constraints
+// match the path parameters is used.
+func (w *APIGatewayProxyWorkflow) getResourceHandler(evt events.APIGatewayProxyRequest, m *routeMatch) *routeMatch {
+	rt, allowedMethods := w.router.lookupResource(evt.HTTPMethod, evt.Resource, func(rt *route) bool {
+		return rt.matchesConditions(evt) && rt.matchesParams(evt.PathParameters)
+	})
+	if rt == nil {
+		m.allowedMethods = allowedMethods
+		return m
+	}
+
+	m.hData = rt.hData
+	return m
+}
+
+func getConditionsMatcher(evt events.APIGatewayProxyRequest) func(*route) bool {
+	return func(rt *route) bool {
+		return rt.matchesConditions(evt)
+	}
+}
+
+func (w *APIGatewayProxyWorkflow) isResourceRoutingEnabled(evt events.APIGatewayProxyRequest) bool {
+	return w.resourceRouting && len(evt.Resource) > 0 && !strings.HasSuffix(evt.Resource, "+}")
+}
+
+// getFallbackHandler returns the handler for requests without handler. If
+// the request path has handlers for other methods, the workflow responds
+// with the allowed methods, otherwise the not found handler is used.
+func (w *APIGatewayProxyWorkflow) getFallbackHandler(evt events.APIGatewayProxyRequest, allowedMethods []string) *handlerData {
+	if len(allowedMethods) == 0 {
+		return w.notFoundHandler
+	}
+
+	handler := newMethodNotAllowedHandler(allowedMethods)
+	if strings.EqualFold(evt.HTTPMethod, http.MethodOptions) {
+		handler = newOptionsHandler(allowedMethods)
+	}
+
+	return &handlerData{handler: handler, preActions: []Action{}, postActions: []Action{}}
+}
+
+// routeMatch is the result of matching request to the workflow routes.
+type routeMatch struct {
+	hData *handlerData
+	// The normalized request path.
+	path string
+	// The path parameters as they are in the request path.
+	pathParams     map[string]string
+	allowedMethods []string
 }
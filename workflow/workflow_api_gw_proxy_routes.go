@@ -0,0 +1,164 @@
+package workflow
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// RouteInfo describes route of the API Gateway proxy workflow.
+type RouteInfo struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	// Conditions are the header, host and query conditions of the route.
+	Conditions []string `json:"conditions,omitempty"`
+	// InputType is the type of the handler input parameter. It is empty
+	// if the handler does not have input parameter.
+	InputType string `json:"inputType,omitempty"`
+	// OutputType is the type of the handler result. It is empty if the
+	// handler returns only error.
+	OutputType string `json:"outputType,omitempty"`
+	// PreActions and PostActions are the numbers of the actions attached
+	// to the route including the route group actions. The workflow
+	// actions are not included.
+	PreActions  int `json:"preActions"`
+	PostActions int `json:"postActions"`
+}
+
+// Routes returns the workflow routes sorted by path and method.
+func (w *APIGatewayProxyWorkflow) Routes() []RouteInfo {
+	routes := w.getSortedRoutes()
+	res := make([]RouteInfo, 0, len(routes))
+	for _, rt := range routes {
+		info := RouteInfo{
+			Method:      strings.ToUpper(rt.method),
+			Path:        rt.path,
+			PreActions:  len(rt.hData.preActions),
+			PostActions: len(rt.hData.postActions),
+		}
+
+		for _, c := range rt.conditions {
+			info.Conditions = append(info.Conditions, c.String())
+		}
+
+		hType := reflect.TypeOf(rt.hData.handler)
+		if hType.NumIn() > 1 {
+			info.InputType = hType.In(1).String()
+		}
+
+		if hType.NumOut() > 1 {
+			info.OutputType = hType.Out(0).String()
+		}
+
+		res = append(res, info)
+	}
+
+	return res
+}
+
+// RoutesJSON returns the workflow routes as JSON array.
+func (w *APIGatewayProxyWorkflow) RoutesJSON() ([]byte, Error) {
+	res, err := json.MarshalIndent(w.Routes(), "", "  ")
+	return res, newError(err)
+}
+
+// RoutesMermaid returns Mermaid flowchart with the actions pipeline of
+// each workflow route.
+func (w *APIGatewayProxyWorkflow) RoutesMermaid() string {
+	buf := new(bytes.Buffer)
+	buf.WriteString("flowchart LR\n")
+	for i, rt := range w.getSortedRoutes() {
+		fmt.Fprintf(buf, "  subgraph r%d[\"%s\"]\n", i, escapeMermaidLabel(rt.String()))
+		steps := getPipeline(w.BaseWorkflow, rt.hData)
+		for j, step := range steps {
+			fmt.Fprintf(buf, "    r%d_%d[\"%s\"]\n", i, j, escapeMermaidLabel(step))
+		}
+
+		for j := 1; j < len(steps); j++ {
+			fmt.Fprintf(buf, "    r%d_%d --> r%d_%d\n", i, j-1, i, j)
+		}
+
+		buf.WriteString("  end\n")
+	}
+
+	return buf.String()
+}
+
+// RoutesDOT returns Graphviz DOT graph with the actions pipeline of each
+// workflow route.
+func (w *APIGatewayProxyWorkflow) RoutesDOT() string {
+	buf := new(bytes.Buffer)
+	buf.WriteString("digraph routes {\n  rankdir=LR;\n")
+	for i, rt := range w.getSortedRoutes() {
+		fmt.Fprintf(buf, "  subgraph cluster_%d {\n    label=%q;\n", i, rt.String())
+		steps := getPipeline(w.BaseWorkflow, rt.hData)
+		for j, step := range steps {
+			fmt.Fprintf(buf, "    r%d_%d [label=%q];\n", i, j, step)
+		}
+
+		for j := 1; j < len(steps); j++ {
+			fmt.Fprintf(buf, "    r%d_%d -> r%d_%d;\n", i, j-1, i, j)
+		}
+
+		buf.WriteString("  }\n")
+	}
+
+	buf.WriteString("}\n")
+	return buf.String()
+}
+
+// getPipeline returns the names of the steps executed for the handler in
+// the order of their execution including the steps of mounted workflows.
+func getPipeline(w *BaseWorkflow, hData *handlerData) []string {
+	steps := []string{}
+	addActions := func(kind string, actions []Action) {
+		for _, a := range actions {
+			steps = append(steps, kind+": "+getFuncName(a))
+		}
+	}
+
+	addActions("workflow pre", w.preActions)
+	addActions("pre", hData.preActions)
+	if hData.mount != nil {
+		steps = append(steps, getPipeline(hData.mount.workflow, hData.mount.hData)...)
+	} else {
+		steps = append(steps, "handler: "+getFuncName(hData.handler))
+	}
+	addActions("post", hData.postActions)
+	addActions("workflow post", w.postActions)
+	return steps
+}
+
+func (w *APIGatewayProxyWorkflow) getSortedRoutes() []*route {
+	routes := append([]*route{}, w.router.routes...)
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].path != routes[j].path {
+			return routes[i].path < routes[j].path
+		}
+
+		if routes[i].method != routes[j].method {
+			return routes[i].method < routes[j].method
+		}
+
+		return routes[i].getConditionsKey() < routes[j].getConditionsKey()
+	})
+
+	return routes
+}
+
+func getFuncName(f interface{}) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(f).Pointer())
+	if fn == nil {
+		return "unknown"
+	}
+
+	return fn.Name()
+}
+
+func escapeMermaidLabel(label string) string {
+	return strings.Replace(label, `"`, "#quot;", -1)
+}
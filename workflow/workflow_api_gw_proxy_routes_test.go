@@ -0,0 +1,63 @@
+package workflow
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAPIGWProxyWorkflowRoutes(t *testing.T) {
+	Convey("API Gateway proxy workflow routes", t, func() {
+		action := func(c Context) error { return nil }
+		b := NewAPIGWProxyWorkflowBuilder().
+			AddPreActions(action).
+			AddGetHandler("/users/{id:int}", func(c Context, req JSONReq) error { return nil }).WithPreActions(action).
+			AddPostHandler("/users", func(c Context, req *JSONReq) error { return nil }).
+			AddGetHandler("/users", func(c Context) error { return nil })
+		b.Group("/admin").
+			AddPreActions(action).
+			AddPostActions(action).
+			AddDeleteHandler("/users/{id}", func(c Context) error { return nil }).WithPostActions(action)
+		w := b.Build()
+
+		Convey("Should return the routes sorted by path and method.", func() {
+			So(w.Routes(), ShouldResemble, []RouteInfo{
+				{Method: http.MethodDelete, Path: "/admin/users/{id}", PreActions: 1, PostActions: 2},
+				{Method: http.MethodGet, Path: "/users"},
+				{Method: http.MethodPost, Path: "/users", InputType: "*workflow.JSONReq"},
+				{Method: http.MethodGet, Path: "/users/{id:int}", InputType: "workflow.JSONReq", PreActions: 1},
+			})
+		})
+
+		Convey("Should export the routes as JSON.", func() {
+			res, err := w.RoutesJSON()
+			So(err, ShouldBeNil)
+
+			routes := []RouteInfo{}
+			So(json.Unmarshal(res, &routes), ShouldBeNil)
+			So(routes, ShouldResemble, w.Routes())
+		})
+
+		Convey("Should export the actions pipeline of the routes as Mermaid flowchart.", func() {
+			res := w.RoutesMermaid()
+
+			So(res, ShouldStartWith, "flowchart LR\n")
+			So(res, ShouldContainSubstring, `subgraph r0["DELETE /admin/users/{id}"]`)
+			So(res, ShouldContainSubstring, "r0_0 --> r0_1")
+			So(strings.Count(res, "workflow pre: "), ShouldEqual, 4)
+			So(strings.Count(res, "subgraph "), ShouldEqual, 4)
+		})
+
+		Convey("Should export the actions pipeline of the routes as DOT graph.", func() {
+			res := w.RoutesDOT()
+
+			So(res, ShouldStartWith, "digraph routes {\n")
+			So(res, ShouldContainSubstring, `label="GET /users/{id:int}";`)
+			So(res, ShouldContainSubstring, "r3_1 -> r3_2;")
+			So(strings.Count(res, "subgraph cluster_"), ShouldEqual, 4)
+		})
+	})
+}
@@ -1,6 +1,7 @@
 package workflow
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -122,9 +123,159 @@ func TestAPIGWProxyWorkflow(t *testing.T) {
 
 			So(err, ShouldBeNil)
 			So(res.StatusCode, ShouldEqual, http.StatusNotFound)
+			So(res.Body, ShouldEqual, `{"message":"not found"}`)
+		})
+
+		Convey("Should invoke the not found handler with the workflow actions.", func() {
+			flow := ""
+			handler := func(c Context) error {
+				flow += "handler"
+				c.SetResponseStatusCode(http.StatusOK)
+				return nil
+			}
+			notFoundHandler := func(c Context, req JSONReq) error {
+				flow += "notFound"
+				c.SetResponse(req).SetResponseStatusCode(http.StatusNotFound)
+				return nil
+			}
+			cors := func(c Context) error {
+				flow += "cors"
+				c.SetResponseHeader("Access-Control-Allow-Origin", "*")
+				return nil
+			}
+
+			w := NewAPIGWProxyWorkflowBuilder().
+				AddPostActions(cors).
+				AddGetHandler("/no-such-path", handler).
+				SetNotFoundHandler(notFoundHandler).
+				WithPreActions(func(c Context) error {
+					flow += "npre"
+					return nil
+				}).
+				Build()
+
+			res, err := w.GetLambdaHandler()(nil, apigwReq)
+
+			So(err, ShouldBeNil)
+			So(flow, ShouldEqual, "nprenotFoundcors")
+			So(res.StatusCode, ShouldEqual, http.StatusNotFound)
+			So(res.Headers, ShouldResemble, map[string]string{"Access-Control-Allow-Origin": "*"})
+			So(res.Body, ShouldEqual, getStringBody(input))
+		})
+
+		Convey("Should respond with bad request for malformed request body with the workflow actions.", func() {
+			flow := ""
+			h := NewAPIGWProxyWorkflowBuilder().
+				AddPreActions(func(c Context) error {
+					flow += "wpre"
+					return nil
+				}).
+				AddPostActions(func(c Context) error {
+					flow += "wpost"
+					return nil
+				}).
+				AddPostHandler("/", func(c Context, req JSONReq) error {
+					flow += "handler"
+					return nil
+				}).
+				Build().
+				GetLambdaHandler()
+
+			evt := getAPIGWProxyRequest(http.MethodPost, "/", nil)
+			evt.Body = `{"message":`
+			res, err := h(nil, evt)
+
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusBadRequest)
+			So(flow, ShouldEqual, "wprewpost")
+
+			body := ErrorResponse{}
+			So(json.Unmarshal([]byte(res.Body), &body), ShouldBeNil)
+			So(body.Message, ShouldEqual, "invalid request")
+			So(body.Errors, ShouldResemble, []FieldError{{Source: "body", Message: "unexpected end of JSON input"}})
+		})
+
+		Convey("Should return method not allowed API Gateway proxy response when the path has handlers for other methods.", func() {
+			handler := func(c Context) error {
+				c.SetResponseStatusCode(http.StatusOK)
+				return nil
+			}
+
+			w := NewAPIGWProxyWorkflowBuilder().
+				AddPutHandler("/", handler).
+				AddPostHandler("/", handler).
+				Build()
+
+			res, err := w.GetLambdaHandler()(nil, apigwReq)
+
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusMethodNotAllowed)
+			So(res.Headers, ShouldResemble, map[string]string{"Allow": "OPTIONS, POST, PUT"})
+			So(res.Body, ShouldEqual, `{"message":"method not allowed"}`)
+		})
+
+		Convey("Should handle HEAD requests with the GET handler and drop the response body.", func() {
+			handler := func(c Context) error {
+				c.SetResponse(input).SetResponseStatusCode(http.StatusOK)
+				return nil
+			}
+
+			w := NewAPIGWProxyWorkflowBuilder().
+				AddGetHandler("/", handler).
+				Build()
+
+			res, err := w.GetLambdaHandler()(nil, getAPIGWProxyRequest(http.MethodHead, "/", nil))
+
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusOK)
 			So(res.Body, ShouldEqual, "")
 		})
 
+		Convey("Should respond to OPTIONS requests with the allowed methods.", func() {
+			handler := func(c Context) error {
+				c.SetResponseStatusCode(http.StatusOK)
+				return nil
+			}
+
+			w := NewAPIGWProxyWorkflowBuilder().
+				AddGetHandler("/", handler).
+				AddDeleteHandler("/", handler).
+				Build()
+
+			res, err := w.GetLambdaHandler()(nil, getAPIGWProxyRequest(http.MethodOptions, "/", nil))
+
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusNoContent)
+			So(res.Headers, ShouldResemble, map[string]string{"Allow": "DELETE, GET, HEAD, OPTIONS"})
+		})
+
+		Convey("Should use the registered HEAD and OPTIONS handlers.", func() {
+			handler := func(c Context) error {
+				c.SetResponseStatusCode(http.StatusTeapot)
+				return nil
+			}
+			getHandler := func(c Context) error {
+				c.SetResponseStatusCode(http.StatusOK)
+				return nil
+			}
+
+			w := NewAPIGWProxyWorkflowBuilder().
+				AddGetHandler("/", getHandler).
+				AddHeadHandler("/", handler).
+				AddOptionsHandler("/", handler).
+				Build()
+
+			res, err := w.GetLambdaHandler()(nil, getAPIGWProxyRequest(http.MethodHead, "/", nil))
+
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusTeapot)
+
+			res, err = w.GetLambdaHandler()(nil, getAPIGWProxyRequest(http.MethodOptions, "/", nil))
+
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusTeapot)
+		})
+
 		Convey("Should return API Gateway proxy response with string request body and string handler input.", func() {
 			input := "test"
 
@@ -198,6 +349,102 @@ func TestAPIGWProxyWorkflow(t *testing.T) {
 			So(*res, ShouldResemble, rawRes)
 		})
 
+		Convey("Should return API Gateway proxy response with the result of typed result handlers.", func() {
+			flow := ""
+			w := NewAPIGWProxyWorkflowBuilder().
+				AddPostActions(func(c Context) error {
+					flow += "wpost"
+					return nil
+				}).
+				AddPostHandler("/", func(c Context, req JSONReq) (JSONReq, error) {
+					return req, nil
+				}).
+				AddGetHandler("/", func(ctx context.Context) (*createdRes, error) {
+					return &createdRes{ID: "1"}, nil
+				}).
+				AddGetHandler("/nil", func(ctx context.Context) (*createdRes, error) {
+					return nil, nil
+				}).
+				AddGetHandler("/accepted", func(c Context) (*createdRes, error) {
+					c.SetResponseStatusCode(http.StatusAccepted)
+					return nil, nil
+				}).
+				AddGetHandler("/error", func(c Context) (*createdRes, error) {
+					return &createdRes{ID: "1"}, fmt.Errorf("test")
+				}).
+				Build()
+
+			res, err := w.GetLambdaHandler()(nil, getAPIGWProxyRequest(http.MethodPost, "/", JSONReq{Message: "test", Code: 1}))
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusOK)
+			So(res.Body, ShouldEqual, getStringBody(JSONReq{Message: "test", Code: 1}))
+
+			res, err = w.GetLambdaHandler()(context.Background(), apigwReq)
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusCreated)
+			So(res.Headers["Location"], ShouldEqual, "/1")
+			So(res.Body, ShouldEqual, getStringBody(createdRes{ID: "1"}))
+
+			res, err = w.GetLambdaHandler()(nil, getAPIGWProxyRequest(http.MethodGet, "/nil", nil))
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusNoContent)
+			So(res.Body, ShouldBeEmpty)
+
+			res, err = w.GetLambdaHandler()(nil, getAPIGWProxyRequest(http.MethodGet, "/accepted", nil))
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusAccepted)
+
+			flow = ""
+			_, err = w.GetLambdaHandler()(nil, getAPIGWProxyRequest(http.MethodGet, "/error", nil))
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "test")
+			So(flow, ShouldEqual, "wpost")
+
+			So(w.Routes()[0].OutputType, ShouldEqual, "*workflow.createdRes")
+		})
+
+		Convey("Should parse the request cookies and render the response cookies.", func() {
+			var cookies []*http.Cookie
+			var session *http.Cookie
+			var noCookieErr error
+			w := NewAPIGWProxyWorkflowBuilder().
+				AddGetHandler("/", func(c Context) error {
+					cookies = c.GetCookies()
+					session, _ = c.GetCookie("session")
+					_, noCookieErr = c.GetCookie("missing")
+					c.AddResponseCookie(&http.Cookie{Name: "session", Value: "new", Path: "/", HttpOnly: true, Secure: true, MaxAge: 60}).
+						AddResponseCookie(&http.Cookie{Name: "theme", Value: "dark", SameSite: http.SameSiteLaxMode}).
+						AddResponseCookie(&http.Cookie{Name: "invalid name", Value: "x"}).
+						SetResponseStatusCode(http.StatusOK)
+					return nil
+				}).
+				Build()
+
+			evt := getAPIGWProxyRequest(http.MethodGet, "/", nil)
+			evt.Headers = map[string]string{"cookie": "session=abc; lang=en"}
+			res, err := w.GetLambdaHandler()(nil, evt)
+
+			So(err, ShouldBeNil)
+			So(len(cookies), ShouldEqual, 2)
+			So(cookies[1].Name, ShouldEqual, "lang")
+			So(session.Value, ShouldEqual, "abc")
+			So(noCookieErr, ShouldEqual, http.ErrNoCookie)
+			So(res.Headers, ShouldBeNil)
+			So(res.MultiValueHeaders, ShouldResemble, map[string][]string{
+				"Set-Cookie": {
+					"session=new; Path=/; Max-Age=60; HttpOnly; Secure",
+					"theme=dark; SameSite=Lax",
+				},
+			})
+
+			evt.MultiValueHeaders = map[string][]string{"Cookie": {"session=first", "lang=de"}}
+			_, err = w.GetLambdaHandler()(nil, evt)
+
+			So(err, ShouldBeNil)
+			So(len(cookies), ShouldEqual, 2)
+			So(session.Value, ShouldEqual, "first")
+		})
+
 		Convey("Should execute pre actions before the handler.", func() {
 			flow := ""
 			handler := func(c Context) error {
@@ -248,6 +495,484 @@ func TestAPIGWProxyWorkflow(t *testing.T) {
 			So(flow, ShouldEqual, "handlerpost1post2")
 		})
 
+		Convey("Should execute the route group actions around the group routes only.", func() {
+			flow := ""
+			action := func(name string) Action {
+				return func(c Context) error {
+					flow += name
+					return nil
+				}
+			}
+			handler := func(c Context) error {
+				flow += "handler"
+				return nil
+			}
+
+			b := NewAPIGWProxyWorkflowBuilder().
+				AddPreActions(action("wpre")).
+				AddPostActions(action("wpost"))
+			b.AddGetHandler("/users", handler)
+
+			admin := b.Group("/v1/admin").
+				AddPreActions(action("gpre")).
+				AddPostActions(action("gpost"))
+			admin.AddGetHandler("/users", handler).WithPreActions(action("hpre")).WithPostActions(action("hpost"))
+			admin.Group("/audit").
+				AddPreActions(action("npre")).
+				AddPostActions(action("npost")).
+				AddGetHandler("/{id}", handler)
+
+			h := b.Build().GetLambdaHandler()
+
+			_, err := h(nil, getAPIGWProxyRequest(http.MethodGet, "/users", nil))
+			So(err, ShouldBeNil)
+			So(flow, ShouldEqual, "wprehandlerwpost")
+
+			flow = ""
+			_, err = h(nil, getAPIGWProxyRequest(http.MethodGet, "/v1/admin/users", nil))
+			So(err, ShouldBeNil)
+			So(flow, ShouldEqual, "wpregprehprehandlerhpostgpostwpost")
+
+			flow = ""
+			_, err = h(nil, getAPIGWProxyRequest(http.MethodGet, "/v1/admin/audit/5", nil))
+			So(err, ShouldBeNil)
+			So(flow, ShouldEqual, "wpregprenprehandlernpostgpostwpost")
+		})
+
+		Convey("Should join the route group prefixes and paths with single slash.", func() {
+			b := NewAPIGWProxyWorkflowBuilder()
+			v1 := b.Group("/v1/")
+			v1.Group("/admin/").AddGetHandler("/users", func(c Context) error { return nil })
+			v1.AddGetHandler("/", func(c Context) error { return nil })
+			w := b.Build()
+
+			paths := []string{}
+			for _, rt := range w.Routes() {
+				paths = append(paths, rt.Path)
+			}
+
+			So(paths, ShouldResemble, []string{"/v1", "/v1/admin/users"})
+
+			res, err := w.GetLambdaHandler()(nil, getAPIGWProxyRequest(http.MethodGet, "/v1/admin/users", nil))
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldNotEqual, http.StatusNotFound)
+		})
+
+		Convey("Should return error when building workflow with route group prefix without leading slash.", func() {
+			b := NewAPIGWProxyWorkflowBuilder()
+			b.Group("v1")
+
+			_, err := b.BuildE()
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "invalid routes: invalid route group: path prefix must start with /, got v1")
+		})
+
+		Convey("Should invoke the mounted workflow routes with the mounted workflow bootstrap and actions.", func() {
+			flow := ""
+			action := func(name string) Action {
+				return func(c Context) error {
+					flow += name
+					return nil
+				}
+			}
+			var injector Injector
+			billing := NewAPIGWProxyWorkflowBuilder().
+				SetBootstrap(func() Injector { return testInjector{} }).
+				AddPreActions(action("mpre")).
+				AddPostActions(action("mpost"))
+			billing.AddGetHandler("/invoices/{id}", func(c Context) error {
+				flow += "handler"
+				injector = c.GetInjector()
+				c.SetResponseStatusCode(http.StatusAccepted).SetResponse(c.GetPathParameter("id"))
+				return nil
+			}).WithPreActions(action("hpre"))
+
+			h := NewAPIGWProxyWorkflowBuilder().
+				AddPreActions(action("wpre")).
+				AddPostActions(action("wpost")).
+				Mount("/billing", billing.Build()).
+				Build().
+				GetLambdaHandler()
+
+			res, err := h(nil, getAPIGWProxyRequest(http.MethodGet, "/billing/invoices/5", nil))
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusAccepted)
+			So(res.Body, ShouldEqual, `"5"`)
+			So(flow, ShouldEqual, "wpremprehprehandlermpostwpost")
+			So(injector, ShouldHaveSameTypeAs, testInjector{})
+
+			res, err = h(nil, getAPIGWProxyRequest(http.MethodGet, "/invoices/5", nil))
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusNotFound)
+		})
+
+		Convey("Should join the mount prefix and the mounted workflow paths.", func() {
+			billing := NewAPIGWProxyWorkflowBuilder()
+			billing.AddGetHandler("/", func(c Context) error {
+				c.SetResponseStatusCode(http.StatusOK)
+				return nil
+			})
+
+			w := NewAPIGWProxyWorkflowBuilder().
+				Mount("/billing/", billing.Build()).
+				Build()
+
+			res, err := w.GetLambdaHandler()(nil, getAPIGWProxyRequest(http.MethodGet, "/billing", nil))
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusOK)
+
+			_, err = NewAPIGWProxyWorkflowBuilder().Mount("billing", billing.Build()).BuildE()
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldStartWith, "invalid routes: invalid mounted workflow: path prefix must start with /, got billing")
+		})
+
+		Convey("Should return error when building workflow with conflicting routes.", func() {
+			handler := func(c Context) error {
+				return nil
+			}
+
+			b := NewAPIGWProxyWorkflowBuilder().
+				AddGetHandler("/users/{id}", handler).
+				AddGetHandler("/users/{id}", handler).
+				AddPostHandler("/users/{userId}", handler)
+
+			w, err := b.BuildE()
+
+			So(w, ShouldBeNil)
+			So(err, ShouldBeError, "invalid routes: duplicate route GET /users/{id}; ambiguous route POST /users/{userId}: path parameter {userId} conflicts with {id} in /users/{id}")
+			So(func() { b.Build() }, ShouldPanic)
+		})
+
+		Convey("Should return error when building workflow with invalid handlers.", func() {
+			_, err := NewAPIGWProxyWorkflowBuilder().
+				AddGetHandler("/", "handler").
+				AddPostHandler("/", func(c Context, req JSONReq, other string) error { return nil }).
+				AddPutHandler("/", func(c Context, req chan int) error { return nil }).
+				AddDeleteHandler("/", func(c Context) {}).
+				BuildE()
+
+			So(err, ShouldBeError, "invalid routes: "+
+				"invalid handler for route GET /: handler must be func, got string; "+
+				"invalid handler for route POST /: handler must have one or two parameters, got func(workflow.Context, workflow.JSONReq, string) error; "+
+				"invalid handler for route PUT /: handler second parameter must be type in which the request can be decoded, got chan int; "+
+				"invalid handler for route DELETE /: handler must return error or result and error, got func(workflow.Context)")
+		})
+
+		Convey("Should extract the named path parameters.", func() {
+			type carReq struct {
+				ID     string `json:"id"`
+				Number string `json:"number"`
+			}
+
+			var params map[string]string
+			var req carReq
+			handler := func(c Context, r carReq) error {
+				params = c.GetPathParameters()
+				req = r
+				return nil
+			}
+
+			w := NewAPIGWProxyWorkflowBuilder().
+				AddGetHandler("/car/{id}/model/{number}", handler).
+				Build()
+
+			_, err := w.GetLambdaHandler()(nil, getAPIGWProxyRequest(http.MethodGet, "/car/5/model/6", nil))
+
+			So(err, ShouldBeNil)
+			So(params, ShouldResemble, map[string]string{"id": "5", "number": "6"})
+			So(req, ShouldResemble, carReq{ID: "5", Number: "6"})
+		})
+
+		Convey("Should convert the path parameters to the input field types.", func() {
+			type userReq struct {
+				ID     int    `json:"id"`
+				Active *bool  `json:"active"`
+				Name   string `json:"name"`
+				Tags   []string
+			}
+
+			var req userReq
+			handler := func(c Context, r userReq) error {
+				req = r
+				return nil
+			}
+
+			w := NewAPIGWProxyWorkflowBuilder().
+				AddPostHandler("/users/{id}", handler).
+				Build()
+
+			evt := getAPIGWProxyRequest(http.MethodPost, "/users/5", map[string]string{"name": "test"})
+			evt.QueryStringParameters = map[string]string{"active": "true", "tags": "a"}
+			_, err := w.GetLambdaHandler()(nil, evt)
+
+			active := true
+			So(err, ShouldBeNil)
+			So(req, ShouldResemble, userReq{ID: 5, Active: &active, Name: "test", Tags: []string{"a"}})
+
+			res, err := w.GetLambdaHandler()(nil, getAPIGWProxyRequest(http.MethodPost, "/users/x", nil))
+
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusBadRequest)
+			So(res.Body, ShouldContainSubstring, `cannot convert \"x\" to int`)
+		})
+
+		Convey("Should convert the path parameters with constraints before binding.", func() {
+			type orderReq struct {
+				ID int `json:"id"`
+			}
+
+			var req orderReq
+			var id string
+			handler := func(c Context, r orderReq) error {
+				req = r
+				id = c.GetPathParameter("id")
+				return nil
+			}
+
+			w := NewAPIGWProxyWorkflowBuilder().
+				AddGetHandler("/orders/{id:int}", handler).
+				Build()
+
+			_, err := w.GetLambdaHandler()(nil, getAPIGWProxyRequest(http.MethodGet, "/orders/42", nil))
+
+			So(err, ShouldBeNil)
+			So(req, ShouldResemble, orderReq{ID: 42})
+			So(id, ShouldEqual, "42")
+
+			type stringOrderReq struct {
+				ID string `json:"id"`
+			}
+
+			var stringReq stringOrderReq
+			w = NewAPIGWProxyWorkflowBuilder().
+				AddGetHandler("/orders/{id:int}", func(c Context, r stringOrderReq) error {
+					stringReq = r
+					return nil
+				}).
+				Build()
+
+			_, err = w.GetLambdaHandler()(nil, getAPIGWProxyRequest(http.MethodGet, "/orders/5", nil))
+
+			So(err, ShouldBeNil)
+			So(stringReq, ShouldResemble, stringOrderReq{ID: "5"})
+
+			res, err := w.GetLambdaHandler()(nil, getAPIGWProxyRequest(http.MethodGet, "/orders/abc", nil))
+
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusNotFound)
+		})
+
+		Convey("Should extract greedy path parameters.", func() {
+			var proxy string
+			handler := func(c Context) error {
+				proxy = c.GetPathParameter("proxy")
+				return nil
+			}
+
+			w := NewAPIGWProxyWorkflowBuilder().
+				AddGetHandler("/files/{proxy+}", handler).
+				Build()
+
+			_, err := w.GetLambdaHandler()(nil, getAPIGWProxyRequest(http.MethodGet, "/files/a/b/c.txt", nil))
+
+			So(err, ShouldBeNil)
+			So(proxy, ShouldEqual, "a/b/c.txt")
+		})
+
+		Convey("Should normalize the request path before matching the routes.", func() {
+			var path, originalPath, name string
+			handler := func(c Context) error {
+				path = c.GetPath()
+				originalPath = c.GetOriginalPath()
+				name = c.GetPathParameter("name")
+				c.SetResponseStatusCode(http.StatusOK)
+				return nil
+			}
+
+			w := NewAPIGWProxyWorkflowBuilder().
+				SetPathNormalization(PathNormalization{
+					StripStage:          true,
+					BasePaths:           []string{"/api"},
+					IgnoreTrailingSlash: true,
+					CollapseSlashes:     true,
+					DecodeSegments:      true,
+				}).
+				AddGetHandler("/users/", handler).
+				AddGetHandler("/files/{name}", handler).
+				Build()
+
+			req := getAPIGWProxyRequest(http.MethodGet, "/prod/api//users/", nil)
+			req.RequestContext.Stage = "prod"
+			res, err := w.GetLambdaHandler()(nil, req)
+
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusOK)
+			So(path, ShouldEqual, "/users")
+			So(originalPath, ShouldEqual, "/prod/api//users/")
+
+			res, err = w.GetLambdaHandler()(nil, getAPIGWProxyRequest(http.MethodGet, "/api/files/a%2Fb.txt", nil))
+
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusOK)
+			So(name, ShouldEqual, "a/b.txt")
+
+			res, err = w.GetLambdaHandler()(nil, getAPIGWProxyRequest(http.MethodGet, "/apiv2/users", nil))
+
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusNotFound)
+		})
+
+		Convey("Should match the routes by the API Gateway resource when resource routing is enabled.", func() {
+			var called string
+			var id interface{}
+			handler := func(name string) func(Context, map[string]interface{}) error {
+				return func(c Context, req map[string]interface{}) error {
+					called = name
+					c.SetResponseStatusCode(http.StatusOK)
+					return nil
+				}
+			}
+			userHandler := func(c Context, req struct {
+				ID int `json:"id"`
+			}) error {
+				called = "user"
+				id = req.ID
+				c.SetResponseStatusCode(http.StatusOK)
+				return nil
+			}
+
+			w := NewAPIGWProxyWorkflowBuilder().
+				SetResourceRouting(true).
+				AddGetHandler("/users/{id:int}", userHandler).
+				AddGetHandler("/users/me", handler("me")).
+				Build()
+
+			req := getAPIGWProxyRequest(http.MethodGet, "/stage/users/5", nil)
+			req.Resource = "/users/{id}"
+			req.PathParameters = map[string]string{"id": "5"}
+			res, err := w.GetLambdaHandler()(nil, req)
+
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusOK)
+			So(called, ShouldEqual, "user")
+			So(id, ShouldEqual, 5)
+
+			req.HTTPMethod = http.MethodPost
+			res, err = w.GetLambdaHandler()(nil, req)
+
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusMethodNotAllowed)
+
+			req = getAPIGWProxyRequest(http.MethodGet, "/users/me", nil)
+			req.Resource = "/{proxy+}"
+			req.PathParameters = map[string]string{"proxy": "users/me"}
+			res, err = w.GetLambdaHandler()(nil, req)
+
+			So(err, ShouldBeNil)
+			So(res.StatusCode, ShouldEqual, http.StatusOK)
+			So(called, ShouldEqual, "me")
+		})
+
+		Convey("Should route the API Gateway resource to the route whose constraints match the path parameters.", func() {
+			var called string
+			handler := func(name string) func(Context) error {
+				return func(c Context) error {
+					called = name
+					c.SetResponseStatusCode(http.StatusOK)
+					return nil
+				}
+			}
+
+			h := NewAPIGWProxyWorkflowBuilder().
+				SetResourceRouting(true).
+				AddGetHandler("/items/{id}", handler("any")).
+				AddGetHandler("/items/{id:int}", handler("int")).
+				AddGetHandler("/items/{id:[a-z]+}", handler("alpha")).
+				Build().
+				GetLambdaHandler()
+
+			request := func(id string) {
+				called = ""
+				req := getAPIGWProxyRequest(http.MethodGet, "/items/"+id, nil)
+				req.Resource = "/items/{id}"
+				req.PathParameters = map[string]string{"id": id}
+				res, err := h(nil, req)
+				So(err, ShouldBeNil)
+				So(res.StatusCode, ShouldEqual, http.StatusOK)
+			}
+
+			request("5")
+			So(called, ShouldEqual, "int")
+
+			request("abc")
+			So(called, ShouldEqual, "alpha")
+
+			request("a-1")
+			So(called, ShouldEqual, "any")
+		})
+
+		Convey("Should choose the most specific route which matches the request conditions.", func() {
+			var called string
+			handler := func(name string) func(Context) error {
+				return func(c Context) error {
+					called = name
+					c.SetResponseStatusCode(http.StatusOK)
+					return nil
+				}
+			}
+
+			w := NewAPIGWProxyWorkflowBuilder().
+				AddGetHandler("/users", handler("v1")).
+				AddGetHandler("/users", handler("v2")).WithHeaderPattern("Accept", `^application/vnd\.acme\.v2\+json`).
+				AddGetHandler("/users", handler("v2-admin")).WithHeader("Accept", "application/vnd.acme.v2+json").WithHost("admin.acme.com").
+				AddGetHandler("/users", handler("v3")).WithQuery("version", "3").
+				AddGetHandler("/orders", handler("orders")).WithHost("orders.acme.com").
+				Build()
+
+			request := func(path string, headers, query map[string]string) int {
+				called = ""
+				req := getAPIGWProxyRequest(http.MethodGet, path, nil)
+				req.Headers = headers
+				req.QueryStringParameters = query
+				res, err := w.GetLambdaHandler()(nil, req)
+				So(err, ShouldBeNil)
+				return res.StatusCode
+			}
+
+			request("/users", nil, nil)
+			So(called, ShouldEqual, "v1")
+
+			request("/users", map[string]string{"accept": "application/vnd.acme.v2+json"}, nil)
+			So(called, ShouldEqual, "v2")
+
+			request("/users", map[string]string{"Accept": "application/vnd.acme.v2+json", "Host": "ADMIN.acme.com"}, nil)
+			So(called, ShouldEqual, "v2-admin")
+
+			request("/users", nil, map[string]string{"version": "3"})
+			So(called, ShouldEqual, "v3")
+
+			request("/users", map[string]string{"accept": "application/vnd.acme.v2+json"}, map[string]string{"version": "3"})
+			So(called, ShouldEqual, "v2")
+
+			So(request("/orders", map[string]string{"Host": "users.acme.com"}, nil), ShouldEqual, http.StatusNotFound)
+			So(called, ShouldEqual, "")
+		})
+
+		Convey("Should return error when building workflow with duplicate route conditions.", func() {
+			handler := func(c Context) error {
+				return nil
+			}
+
+			_, err := NewAPIGWProxyWorkflowBuilder().
+				AddGetHandler("/users", handler).WithHeader("Accept", "v2").WithQuery("q", "1").
+				AddGetHandler("/users", handler).WithQuery("q", "1").WithHeader("Accept", "v2").
+				AddGetHandler("/users", handler).WithHeaderPattern("Accept", "[").
+				BuildE()
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldStartWith, "invalid routes: duplicate route GET /users [header Accept=v2, query q=1]; invalid route GET /users: invalid header condition pattern [:")
+		})
+
 		Convey("Should handle paths correctly", func() {
 			type testCase struct {
 				testName        string
@@ -349,3 +1074,19 @@ func getAPIGWProxyRequest(method, path string, input interface{}) events.APIGate
 		HTTPMethod: method,
 	}
 }
+
+type testInjector struct{}
+
+func (testInjector) Resolve(out interface{}) error { return nil }
+
+func (testInjector) ResolveByName(name string, out interface{}) error { return nil }
+
+type createdRes struct {
+	ID string `json:"id"`
+}
+
+func (createdRes) StatusCode() int { return http.StatusCreated }
+
+func (r createdRes) Headers() map[string]string {
+	return map[string]string{"Location": "/" + r.ID}
+}
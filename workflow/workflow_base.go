@@ -3,6 +3,7 @@ package workflow
 import (
 	"context"
 	"encoding/json"
+	"net/http"
 	"reflect"
 )
 
@@ -11,37 +12,53 @@ type BaseWorkflow struct {
 	bootstrap   Bootstrap
 	preActions  []Action
 	postActions []Action
+	validator   *validator
+	// invalidRequestHandler sets the response for request which is not valid.
+	// If it is not set, the validation error is returned as handler error.
+	invalidRequestHandler func(c Context, err *ValidationError)
 }
 
+// StatusCoder is implemented by the handler results which set the
+// response status code.
+type StatusCoder interface {
+	StatusCode() int
+}
+
+// Headerer is implemented by the handler results which set response headers.
+type Headerer interface {
+	Headers() map[string]string
+}
+
+// contextOption sets additional request specific data to the handler context.
+type contextOption func(c *lambdaCtx)
+
 // InvokeHandler invokes the provided handler.
 func (w *BaseWorkflow) InvokeHandler(awsContext context.Context, evt interface{}, evtBytes []byte, hData *handlerData) (Context, Error) {
+	return w.invokeHandler(awsContext, evt, evtBytes, hData)
+}
+
+func (w *BaseWorkflow) invokeHandler(awsContext context.Context, evt interface{}, evtBytes []byte, hData *handlerData, opts ...contextOption) (Context, Error) {
 	req, err := w.getReqParamIfAny(hData.handler, evtBytes)
 	if err != nil {
-		return w.createContext(awsContext, evt, nil), err
+		return w.createContext(awsContext, evt, nil, opts...), err
 	}
 
+	return w.invoke(awsContext, evt, req, hData, opts...)
+}
+
+// invoke executes the workflow actions and the handler with the already
+// decoded request.
+func (w *BaseWorkflow) invoke(awsContext context.Context, evt interface{}, req *reflect.Value, hData *handlerData, opts ...contextOption) (*lambdaCtx, Error) {
 	// Create handler workflow context and register the dependencies
 	// in the bootstrap if there are any.
-	hContext := w.createContext(awsContext, evt, req)
+	hContext := w.createContext(awsContext, evt, req, opts...)
 
 	// Execute Pre Actions.
-	err = w.executeActions(hContext, w.preActions)
+	err := w.executeActions(hContext, w.preActions)
 	if err != nil {
 		return hContext, err
 	}
 
-	// Add the handler context to the handler func.
-	in := []reflect.Value{
-		reflect.ValueOf(hContext),
-	}
-
-	// Add request parameter to the handler input if there is
-	// input parameter.
-	if req != nil {
-		in = append(in, *req)
-	}
-
-	hValue := reflect.ValueOf(hData.handler)
 	// Execute Pre Handler Actions.
 	err = w.executeActions(hContext, hData.preActions)
 	// Return result if the pre actions return error or
@@ -50,18 +67,69 @@ func (w *BaseWorkflow) InvokeHandler(awsContext context.Context, evt interface{}
 		return hContext, err
 	}
 
-	// Invoke the provided handler.
-	out := hValue.Call(in)
+	// The handler is not invoked with invalid request. The request
+	// of mounted workflow is validated by the mounted workflow.
+	valid := true
+	if hData.mount == nil {
+		valid, err = w.validateRequest(hContext, req)
+		if err != nil {
+			return hContext, err
+		}
+	}
 
-	resErr := out[0].Interface()
-	if resErr != nil {
-		err, ok := resErr.(error)
-		if !ok {
-			return hContext, newErrorWithMessage("invalid handler error result")
+	if hData.mount != nil {
+		// Invoke the mounted workflow with its own context and
+		// use its response as handler response.
+		mContext, err := hData.mount.workflow.invoke(awsContext, evt, req, hData.mount.hData, opts...)
+		if err != nil && mContext.handlerErr == nil {
+			return hContext, err
+		}
+
+		hContext.setResponseFrom(mContext)
+	} else if valid && hData.typed != nil {
+		// Invoke the typed handler with its input without reflection.
+		res, err := hData.typed.invoke(hContext, hContext.input)
+		if err != nil {
+			hContext.handlerErr = err
+		} else {
+			setHandlerResult(hContext, res)
+		}
+	} else if valid {
+		hValue := reflect.ValueOf(hData.handler)
+		// Add the handler context or the AWS Lambda context to the handler func.
+		in := []reflect.Value{
+			reflect.ValueOf(hContext),
 		}
 
-		// Set the handler error only if the handler has returned valid error.
-		hContext.handlerErr = err
+		if hValue.Type().In(0) == lambdaContextType {
+			if awsContext == nil {
+				awsContext = context.Background()
+			}
+
+			in[0] = reflect.ValueOf(awsContext)
+		}
+
+		// Add request parameter to the handler input if there is
+		// input parameter.
+		if req != nil {
+			in = append(in, *req)
+		}
+
+		// Invoke the provided handler.
+		out := hValue.Call(in)
+
+		resErr := out[len(out)-1].Interface()
+		if resErr != nil {
+			err, ok := resErr.(error)
+			if !ok {
+				return hContext, newErrorWithMessage("invalid handler error result")
+			}
+
+			// Set the handler error only if the handler has returned valid error.
+			hContext.handlerErr = err
+		} else if len(out) > 1 {
+			setHandlerResult(hContext, out[0].Interface())
+		}
 	}
 
 	// Execute Post Handler Actions.
@@ -79,13 +147,18 @@ func (w *BaseWorkflow) InvokeHandler(awsContext context.Context, evt interface{}
 	return hContext, newError(hContext.handlerErr)
 }
 
-func (w *BaseWorkflow) createContext(ctx context.Context, evt interface{}, req *reflect.Value) *lambdaCtx {
+func (w *BaseWorkflow) createContext(ctx context.Context, evt interface{}, req *reflect.Value, opts ...contextOption) *lambdaCtx {
 	var injector Injector
 	if !reflect.ValueOf(w.bootstrap).IsNil() {
 		injector = w.bootstrap()
 	}
 
-	return &lambdaCtx{lambdaContext: ctx, lambdaEvent: evt, injector: injector, req: req}
+	c := &lambdaCtx{lambdaContext: ctx, lambdaEvent: evt, injector: injector, req: req}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
 }
 
 func (w *BaseWorkflow) getHandlerInputFromEvent(handler interface{}, evt []byte) (reflect.Value, Error) {
@@ -113,6 +186,58 @@ func (w *BaseWorkflow) getHandlerInputFromEvent(handler interface{}, evt []byte)
 	return inputValue.Elem(), nil
 }
 
+// setHandlerResult sets the result of handler which returns result and
+// error as response. The response status code is set to 200, or to 204 for
+// nil result, if it is not set by the handler or by the result.
+func setHandlerResult(c *lambdaCtx, result interface{}) {
+	if v := reflect.ValueOf(result); !v.IsValid() || (v.Kind() == reflect.Ptr && v.IsNil()) {
+		if c.responseStatusCode == 0 {
+			c.SetResponseStatusCode(http.StatusNoContent)
+		}
+
+		return
+	}
+
+	c.SetResponse(result)
+	if sc, ok := result.(StatusCoder); ok {
+		c.SetResponseStatusCode(sc.StatusCode())
+	} else if c.responseStatusCode == 0 {
+		c.SetResponseStatusCode(http.StatusOK)
+	}
+
+	if h, ok := result.(Headerer); ok {
+		for k, v := range h.Headers() {
+			c.SetResponseHeader(k, v)
+		}
+	}
+}
+
+// validateRequest validates the request and sets the validation error
+// response if it is invalid. Returns whether the request is valid.
+func (w *BaseWorkflow) validateRequest(c *lambdaCtx, req *reflect.Value) (bool, Error) {
+	if req == nil || w.validator == nil {
+		return true, nil
+	}
+
+	err := w.validator.validate(*req)
+	if err == nil {
+		return true, nil
+	}
+
+	vErr, ok := err.(*ValidationError)
+	if !ok {
+		return false, newError(err)
+	}
+
+	if w.invalidRequestHandler != nil {
+		w.invalidRequestHandler(c, vErr)
+	} else {
+		c.handlerErr = vErr
+	}
+
+	return false, nil
+}
+
 func (w *BaseWorkflow) executeActions(c Context, actions []Action) Error {
 	for _, a := range actions {
 		err := a(c)